@@ -0,0 +1,279 @@
+// Package cli implements the non-interactive subcommands, for scripts.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/types/views"
+)
+
+// UsageError is returned for bad arguments, main exits with status 2.
+type UsageError struct {
+	error
+}
+
+func usageErrorf(format string, a ...any) error {
+	return UsageError{fmt.Errorf(format, a...)}
+}
+
+const Usage = `Commands:
+  list [--json]                 list this device and its peers
+  show [--json] <host>          show the details of a device
+  ping [--json] [flags] <host>  ping a device until a direct connection is made,
+                                flags: -c, --interval and --timeout default to the
+                                -ping-* ones, --type disco|TSMP|ICMP|peerapi
+`
+
+// Run runs the subcommand named by args[0], writing its output to w.
+func Run(b ts.Backend, pingOpts ts.PingOptions, args []string, w io.Writer) error {
+	name, args := args[0], args[1:]
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	switch name {
+	case "list":
+		if _, err := parseArgs(fs, args, 0); err != nil {
+			return err
+		}
+		status, err := getStatus(b)
+		if err != nil {
+			return err
+		}
+		return list(w, status, *asJSON)
+	case "show":
+		host, err := parseArgs(fs, args, 1)
+		if err != nil {
+			return err
+		}
+		status, err := getStatus(b)
+		if err != nil {
+			return err
+		}
+		peer, err := ts.FindPeer(status, host[0])
+		if err != nil {
+			return err
+		}
+		return show(w, status, peer, *asJSON)
+	case "ping":
+		fs.IntVar(&pingOpts.Count, "c", pingOpts.Count, "max number of pings to send, 0 for no limit")
+		fs.DurationVar(&pingOpts.Interval, "interval", pingOpts.Interval, "wait between ping replies")
+		fs.DurationVar(&pingOpts.Timeout, "timeout", pingOpts.Timeout, "timeout before giving up on a ping")
+		pingType := fs.String("type", "disco", "ping type: disco, TSMP, ICMP or peerapi")
+		host, err := parseArgs(fs, args, 1)
+		if err != nil {
+			return err
+		}
+		t, err := parsePingType(*pingType)
+		if err != nil {
+			return err
+		}
+		status, err := getStatus(b)
+		if err != nil {
+			return err
+		}
+		peer, err := ts.FindPeer(status, host[0])
+		if err != nil {
+			return err
+		}
+		return ping(w, b, peer, t, pingOpts, *asJSON)
+	}
+	return usageErrorf("unknown command %q", name)
+}
+
+// parseArgs parses fs, allowing flags after the positional arguments, and
+// checks that there are exactly n of those.
+func parseArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, UsageError{err}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != n {
+		if n == 0 {
+			return nil, usageErrorf("%s takes no arguments", fs.Name())
+		}
+		return nil, usageErrorf("usage: tailscale-tui %s <host>", fs.Name())
+	}
+	return positional, nil
+}
+
+func getStatus(b ts.Backend) (*ipnstate.Status, error) {
+	status, err := ts.GetStatus(b)
+	if err != nil {
+		return nil, err
+	}
+	if ts.NeedsLogin(status) {
+		return nil, errors.New("not logged in, run tailscale-tui to log in")
+	}
+	return status, nil
+}
+
+// peer is the stable JSON form of a device.
+type peer struct {
+	ID             string     `json:"id"`
+	HostName       string     `json:"hostname"`
+	DNSName        string     `json:"dns_name"`
+	OS             string     `json:"os"`
+	User           string     `json:"user"`
+	Self           bool       `json:"self"`
+	Online         bool       `json:"online"`
+	IPs            []string   `json:"ips"`
+	Relay          string     `json:"relay"`
+	ExitNode       bool       `json:"exit_node"`
+	ExitNodeOption bool       `json:"exit_node_option"`
+	Tags           []string   `json:"tags"`
+	PrimaryRoutes  []string   `json:"primary_routes"`
+	AllowedIPs     []string   `json:"allowed_ips"`
+	KeyExpiry      *time.Time `json:"key_expiry"`
+	LastSeen       *time.Time `json:"last_seen"`
+	RxBytes        int64      `json:"rx_bytes"`
+	TxBytes        int64      `json:"tx_bytes"`
+}
+
+func newPeer(status *ipnstate.Status, p *ipnstate.PeerStatus) peer {
+	out := peer{
+		ID:             string(p.ID),
+		HostName:       p.HostName,
+		DNSName:        strings.TrimSuffix(p.DNSName, "."),
+		OS:             p.OS,
+		User:           ts.OwnerLogin(status, p),
+		Self:           p.ID == status.Self.ID,
+		Online:         p.Online,
+		IPs:            []string{},
+		Relay:          p.Relay,
+		ExitNode:       p.ExitNode,
+		ExitNodeOption: p.ExitNodeOption,
+		Tags:           []string{},
+		PrimaryRoutes:  []string{},
+		AllowedIPs:     []string{},
+		KeyExpiry:      p.KeyExpiry,
+		RxBytes:        p.RxBytes,
+		TxBytes:        p.TxBytes,
+	}
+	for _, ip := range p.TailscaleIPs {
+		out.IPs = append(out.IPs, ip.String())
+	}
+	if p.Tags != nil {
+		out.Tags = append(out.Tags, p.Tags.AsSlice()...)
+	}
+	if p.PrimaryRoutes != nil {
+		out.PrimaryRoutes = prefixes(*p.PrimaryRoutes)
+	}
+	if p.AllowedIPs != nil {
+		out.AllowedIPs = prefixes(*p.AllowedIPs)
+	}
+	if !p.LastSeen.IsZero() {
+		lastSeen := p.LastSeen
+		out.LastSeen = &lastSeen
+	}
+	return out
+}
+
+func prefixes[T fmt.Stringer](s views.Slice[T]) []string {
+	list := []string{}
+	for i := range s.Len() {
+		list = append(list, s.At(i).String())
+	}
+	return list
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func notes(p peer) string {
+	var notes []string
+	if p.Self {
+		notes = append(notes, "this device")
+	}
+	if p.ExitNode {
+		notes = append(notes, "exit node")
+	} else if p.ExitNodeOption {
+		notes = append(notes, "offers exit node")
+	}
+	return strings.Join(notes, ", ")
+}
+
+func onlineString(online bool) string {
+	if online {
+		return "online"
+	}
+	return "offline"
+}
+
+func list(w io.Writer, status *ipnstate.Status, asJSON bool) error {
+	peers := []peer{}
+	for _, p := range ts.SortedPeers(status) {
+		peers = append(peers, newPeer(status, p))
+	}
+	if asJSON {
+		return writeJSON(w, peers)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tIP\tOS\tUSER\tSTATUS\tNOTES")
+	for _, p := range peers {
+		ip := "-"
+		if len(p.IPs) > 0 {
+			ip = p.IPs[0]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", p.HostName, ip, p.OS, p.User, onlineString(p.Online), notes(p))
+	}
+	return tw.Flush()
+}
+
+func show(w io.Writer, status *ipnstate.Status, ps *ipnstate.PeerStatus, asJSON bool) error {
+	p := newPeer(status, ps)
+	if asJSON {
+		return writeJSON(w, p)
+	}
+	keyExpiry := "disabled"
+	if p.KeyExpiry != nil {
+		keyExpiry = p.KeyExpiry.Local().Format(time.RFC3339)
+		if ps.Expired {
+			keyExpiry += " (expired)"
+		}
+	}
+	lastSeen := "-"
+	if p.LastSeen != nil {
+		lastSeen = p.LastSeen.Local().Format(time.RFC3339)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, field := range [][2]string{
+		{"Host", p.HostName},
+		{"DNS name", p.DNSName},
+		{"ID", p.ID},
+		{"OS", p.OS},
+		{"User", p.User},
+		{"Status", onlineString(p.Online)},
+		{"IPs", strings.Join(p.IPs, ", ")},
+		{"Relay", p.Relay},
+		{"Tags", strings.Join(p.Tags, ", ")},
+		{"Routes", strings.Join(p.PrimaryRoutes, ", ")},
+		{"Allowed IPs", strings.Join(p.AllowedIPs, ", ")},
+		{"Key expiry", keyExpiry},
+		{"Last seen", lastSeen},
+		{"Traffic", fmt.Sprintf("rx %s, tx %s", ts.FormatBytes(p.RxBytes), ts.FormatBytes(p.TxBytes))},
+		{"Notes", notes(p)},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", field[0], field[1])
+	}
+	return tw.Flush()
+}
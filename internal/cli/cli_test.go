@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"tailscale.com/ipn/ipnstate"
+)
+
+// peerFields are the JSON fields of a device, scripts depend on them.
+var peerFields = []string{
+	"allowed_ips", "dns_name", "exit_node", "exit_node_option", "hostname", "id", "ips", "key_expiry",
+	"last_seen", "online", "os", "primary_routes", "relay", "rx_bytes", "self", "tags", "tx_bytes", "user",
+}
+
+func run(t *testing.T, f *ts.FakeBackend, args ...string) (string, error) {
+	t.Helper()
+	opts := ts.PingOptions{Count: 5, Timeout: time.Second}
+	var out bytes.Buffer
+	err := Run(f, opts, args, &out)
+	return out.String(), err
+}
+
+// fields returns the words of each line of a table.
+func fields(out string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+func keys(v map[string]any) []string {
+	return slices.Sorted(maps.Keys(v))
+}
+
+func TestList(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	out, err := run(t, f, "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"HOST", "IP", "OS", "USER", "STATUS", "NOTES"},
+		{"laptop", "100.64.0.1", "linux", "me@example.com", "online", "this", "device"},
+		{"exit", "100.64.0.3", "linux", "me@example.com", "online", "offers", "exit", "node"},
+		{"server", "100.64.0.2", "linux", "me@example.com", "online"},
+		{"ci", "100.64.0.5", "windows", "tagged", "device", "online"},
+		{"phone", "100.64.0.4", "android", "alice@example.com", "offline"},
+	}
+	if got := fields(out); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("list =\n%s\nwant rows %q", out, want)
+	}
+
+	out, err = run(t, f, "list", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var peers []map[string]any
+	if err := json.Unmarshal([]byte(out), &peers); err != nil {
+		t.Fatalf("list --json: %v\n%s", err, out)
+	}
+	var hosts []string
+	for _, p := range peers {
+		if got := keys(p); !slices.Equal(got, peerFields) {
+			t.Errorf("list --json fields %q, want %q", got, peerFields)
+		}
+		hosts = append(hosts, p["hostname"].(string))
+	}
+	if want := []string{"laptop", "exit", "server", "ci", "phone"}; !slices.Equal(hosts, want) {
+		t.Errorf("list --json hosts %q, want %q", hosts, want)
+	}
+}
+
+func TestShow(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	out, err := run(t, f, "show", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Host: server", "DNS name: server.tailnet.ts.net", "Routes: 10.0.0.0/24", "Key expiry: disabled", "Traffic: rx 2.9 KiB, tx 1000 B"} {
+		if !slices.ContainsFunc(fields(out), func(row []string) bool { return strings.Join(row, " ") == want }) {
+			t.Errorf("show server =\n%s\nwant a line %q", out, want)
+		}
+	}
+
+	out, err = run(t, f, "show", "phone", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "id": "phone",
+  "hostname": "phone",
+  "dns_name": "phone.tailnet.ts.net",
+  "os": "android",
+  "user": "alice@example.com",
+  "self": false,
+  "online": false,
+  "ips": [
+    "100.64.0.4"
+  ],
+  "relay": "",
+  "exit_node": false,
+  "exit_node_option": false,
+  "tags": [],
+  "primary_routes": [],
+  "allowed_ips": [],
+  "key_expiry": null,
+  "last_seen": "2024-01-01T00:00:00Z",
+  "rx_bytes": 0,
+  "tx_bytes": 0
+}
+`
+	if out != want {
+		t.Errorf("show --json phone =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := run(t, f, "show", "nas"); err == nil {
+		t.Error("show of an unknown host succeeded")
+	}
+	if _, err := run(t, f, "show"); !errors.As(err, &UsageError{}) {
+		t.Errorf("show without a host: error %v, want a usage error", err)
+	}
+}
+
+func queuePings(f *ts.FakeBackend) {
+	f.QueuePing(netip.MustParseAddr("100.64.0.2"),
+		ts.FakePing{Result: &ipnstate.PingResult{NodeName: "server", NodeIP: "100.64.0.2", DERPRegionID: 1, DERPRegionCode: "nyc", LatencySeconds: 0.04}},
+		ts.FakePing{Result: &ipnstate.PingResult{NodeName: "server", NodeIP: "100.64.0.2", Endpoint: "192.0.2.1:41641", LatencySeconds: 0.01}},
+	)
+}
+
+func TestPing(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	queuePings(f)
+	out, err := run(t, f, "ping", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pong from server (100.64.0.2) via DERP(nyc) in 40ms\n" +
+		"pong from server (100.64.0.2) via 192.0.2.1:41641 in 10ms\n" +
+		"\n" +
+		"2 sent, 2 received, 0% loss\n" +
+		"rtt min/avg/max/jitter = 10.0/25.0/40.0/30.0 ms\n"
+	if out != want {
+		t.Errorf("ping server =\n%s\nwant\n%s", out, want)
+	}
+
+	queuePings(f)
+	out, err = run(t, f, "ping", "--json", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("ping --json: %v\n%s", err, out)
+	}
+	resultFields := []string{"avg_ms", "direct", "host", "ip", "jitter_ms", "loss_percent", "max_ms", "min_ms", "received", "replies", "sent", "type"}
+	if got := keys(result); !slices.Equal(got, resultFields) {
+		t.Errorf("ping --json fields %q, want %q", got, resultFields)
+	}
+	replies := result["replies"].([]any)
+	replyFields := []string{"direct", "latency_ms", "seq", "via"}
+	for _, r := range replies {
+		if got := keys(r.(map[string]any)); !slices.Equal(got, replyFields) {
+			t.Errorf("ping --json reply fields %q, want %q", got, replyFields)
+		}
+	}
+	if len(replies) != 2 || result["direct"] != true || result["sent"] != 2.0 || result["type"] != "disco" {
+		t.Errorf("ping --json =\n%s\nwant 2 disco replies, the last one direct", out)
+	}
+
+	f.QueuePing(netip.MustParseAddr("100.64.0.2"), ts.FakePing{Err: errors.New("unreachable")})
+	out, err = run(t, f, "ping", "--json", "-c", "1", "server")
+	if err == nil || !strings.Contains(out, `"error": "unreachable"`) {
+		t.Errorf("ping without a reply: error %v, output\n%s\nwant the error in the reply", err, out)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	for _, args := range [][]string{
+		{"status"},
+		{"list", "server"},
+		{"list", "--yaml"},
+		{"ping", "--type", "udp", "server"},
+	} {
+		if _, err := run(t, f, args...); !errors.As(err, &UsageError{}) {
+			t.Errorf("%q: error %v, want a usage error", args, err)
+		}
+	}
+}
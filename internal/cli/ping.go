@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+)
+
+func parsePingType(s string) (tailcfg.PingType, error) {
+	i := slices.IndexFunc(ts.PingTypes, func(t tailcfg.PingType) bool { return strings.EqualFold(string(t), s) })
+	if i < 0 {
+		return "", usageErrorf("unknown ping type %q", s)
+	}
+	return ts.PingTypes[i], nil
+}
+
+// pingReply is the stable JSON form of a single ping.
+type pingReply struct {
+	Seq       int     `json:"seq"`
+	LatencyMS float64 `json:"latency_ms"`
+	Via       string  `json:"via"`
+	Direct    bool    `json:"direct"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// pingResult is the stable JSON form of a ping run.
+type pingResult struct {
+	Host        string      `json:"host"`
+	IP          string      `json:"ip"`
+	Type        string      `json:"type"`
+	Replies     []pingReply `json:"replies"`
+	Sent        int         `json:"sent"`
+	Received    int         `json:"received"`
+	LossPercent float64     `json:"loss_percent"`
+	MinMS       float64     `json:"min_ms"`
+	AvgMS       float64     `json:"avg_ms"`
+	MaxMS       float64     `json:"max_ms"`
+	JitterMS    float64     `json:"jitter_ms"`
+	Direct      bool        `json:"direct"`
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ping pings p like the details view does: disco pings go on until the path
+// is direct or opts.Count is reached, the other types stop at the first
+// reply. Interrupting it still prints the statistics.
+func ping(w io.Writer, b ts.Backend, p *ipnstate.PeerStatus, pingType tailcfg.PingType, opts ts.PingOptions, asJSON bool) error {
+	if len(p.TailscaleIPs) == 0 {
+		return fmt.Errorf("%s has no Tailscale IP", p.HostName)
+	}
+	ip := p.TailscaleIPs[0]
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats := ts.NewPingStats(pingType)
+	result := pingResult{Host: p.HostName, IP: ip.String(), Type: string(pingType), Replies: []pingReply{}}
+	for seq := 1; opts.Count == 0 || seq <= opts.Count; seq++ {
+		pr, err := ts.Ping(ctx, b, ip, pingType, opts.Timeout)
+		if ctx.Err() != nil {
+			break
+		}
+		stats.Add(pr, err)
+		reply := pingReply{Seq: seq}
+		var line string
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			reply.Error = "timed out"
+			line = fmt.Sprintf("ping %q timed out", ip)
+		case err != nil:
+			reply.Error = err.Error()
+			line = fmt.Sprintf("error: %s", err)
+		default:
+			line, err = ts.PingResultString(pr, pingType)
+			if err != nil {
+				reply.Error, line = err.Error(), err.Error()
+			} else {
+				reply.LatencyMS = ms(time.Duration(pr.LatencySeconds * float64(time.Second)))
+				reply.Via = ts.PingVia(pr, pingType)
+				reply.Direct = pr.Endpoint != ""
+			}
+		}
+		result.Replies = append(result.Replies, reply)
+		if !asJSON {
+			fmt.Fprintln(w, line)
+		}
+		if reply.Error == "" && (reply.Direct || pingType != tailcfg.PingDisco) {
+			result.Direct = reply.Direct
+			break
+		}
+		if reply.Error == "" && opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.Interval):
+			}
+		}
+	}
+
+	minRTT, avgRTT, maxRTT, jitter := stats.RTT()
+	result.Sent, result.Received, result.LossPercent = stats.Sent(), stats.Received(), stats.Loss()
+	result.MinMS, result.AvgMS, result.MaxMS, result.JitterMS = ms(minRTT), ms(avgRTT), ms(maxRTT), ms(jitter)
+	if asJSON {
+		if err := writeJSON(w, result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(w)
+		for _, line := range stats.Summary() {
+			fmt.Fprintln(w, line)
+		}
+	}
+	if stats.Received() == 0 {
+		return errors.New("no reply")
+	}
+	return nil
+}
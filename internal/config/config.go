@@ -0,0 +1,430 @@
+// Package config loads the user configuration file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Ping struct {
+	Count    int           `toml:"count"`
+	Interval time.Duration `toml:"interval"`
+	Timeout  time.Duration `toml:"timeout"`
+}
+
+type Probe struct {
+	Enabled     bool          `toml:"enabled"`
+	Concurrency int           `toml:"concurrency"`
+	Rate        time.Duration `toml:"rate"`
+	Interval    time.Duration `toml:"interval"`
+}
+
+type Refresh struct {
+	// BusDelay coalesces bursts of IPN bus notifications into a single
+	// status refresh.
+	BusDelay time.Duration `toml:"bus_delay"`
+	// LoginPoll is how often the status is refreshed while waiting for a
+	// login to complete without a working IPN bus connection.
+	LoginPoll time.Duration `toml:"login_poll"`
+	// Traffic is how often the status is refreshed to update the traffic
+	// rates of the peers, 0 to only refresh it on changes.
+	Traffic time.Duration `toml:"traffic"`
+}
+
+// List holds the defaults of the node list.
+type List struct {
+	// Sort is the default order, one of the ts.PeerSort names.
+	Sort       string `toml:"sort"`
+	Descending bool   `toml:"descending"`
+	// Group is the default grouping, one of the ts.PeerGrouping names.
+	Group string `toml:"group"`
+}
+
+type Config struct {
+	Ping    Ping    `toml:"ping"`
+	Probe   Probe   `toml:"probe"`
+	Refresh Refresh `toml:"refresh"`
+	List    List    `toml:"list"`
+	// Theme is a built-in theme or a file in the themes directory next to
+	// the config file, the default is monochrome when NO_COLOR is set.
+	Theme string `toml:"theme"`
+	// Background is auto, light or dark.
+	Background string           `toml:"background"`
+	Colors     map[string]Color `toml:"colors"`
+	Keys       map[string]Keys  `toml:"keys"`
+	// Filters are node list queries by name, recalled with a key.
+	Filters map[string]string `toml:"filters"`
+
+	// palette is Theme as loaded, themeFile the file it comes from.
+	palette   constants.Theme
+	themeFile string
+}
+
+// Color is a color for both backgrounds or a table with a light and a dark
+// one.
+type Color lipgloss.AdaptiveColor
+
+func (c *Color) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*c = Color{Light: v, Dark: v}
+		return nil
+	case map[string]any:
+		light, lok := v["light"].(string)
+		dark, dok := v["dark"].(string)
+		if !lok || !dok || len(v) != 2 {
+			return fmt.Errorf("expected a table with a light and a dark color, got %v", v)
+		}
+		*c = Color{Light: light, Dark: dark}
+		return nil
+	}
+	return fmt.Errorf("expected a color or a table with a light and a dark one, got %v", v)
+}
+
+func adaptive(colors map[string]Color) map[string]lipgloss.AdaptiveColor {
+	m := map[string]lipgloss.AdaptiveColor{}
+	for name, c := range colors {
+		m[name] = lipgloss.AdaptiveColor(c)
+	}
+	return m
+}
+
+// KeyList is a single key or a list of keys.
+type KeyList []string
+
+func (k *KeyList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*k = KeyList{v}
+		return nil
+	case []any:
+		*k = nil
+		for _, key := range v {
+			s, ok := key.(string)
+			if !ok {
+				return fmt.Errorf("keys must be strings, got %v", key)
+			}
+			*k = append(*k, s)
+		}
+		return nil
+	}
+	return fmt.Errorf("expected a key or a list of keys, got %v", v)
+}
+
+// Keys are either the keys of a binding, used in every view, or a table
+// named after a view with keys for that view only.
+type Keys struct {
+	Keys KeyList
+	View map[string]KeyList
+}
+
+func (k *Keys) UnmarshalTOML(v any) error {
+	view, ok := v.(map[string]any)
+	if !ok {
+		return k.Keys.UnmarshalTOML(v)
+	}
+	k.View = map[string]KeyList{}
+	for name, v := range view {
+		var keys KeyList
+		if err := keys.UnmarshalTOML(v); err != nil {
+			return fmt.Errorf("key binding %s: %w", name, err)
+		}
+		k.View[name] = keys
+	}
+	return nil
+}
+
+func Default() Config {
+	ping := ts.DefaultPingOptions()
+	probe := ts.DefaultProbeOptions()
+	return Config{
+		Ping: Ping{
+			Count:    ping.Count,
+			Interval: ping.Interval,
+			Timeout:  ping.Timeout,
+		},
+		Probe: Probe{
+			Enabled:     probe.Enabled,
+			Concurrency: probe.Concurrency,
+			Rate:        probe.Rate,
+			Interval:    probe.Interval,
+		},
+		Refresh: Refresh{
+			BusDelay:  250 * time.Millisecond,
+			LoginPoll: 2 * time.Second,
+			Traffic:   2 * time.Second,
+		},
+		List: List{
+			Sort:  ts.SortDefault.Name(),
+			Group: ts.GroupNone.Name(),
+		},
+		Background: "auto",
+		palette:    defaultTheme(),
+	}
+}
+
+func defaultTheme() constants.Theme {
+	if os.Getenv("NO_COLOR") != "" {
+		return constants.Themes["monochrome"]
+	}
+	return constants.Themes["default"]
+}
+
+// loadTheme returns the built-in theme name, or the one in the file name.toml
+// in dir. Theme files set colors like the config file does, on top of a
+// built-in base theme.
+func loadTheme(dir, name string) (constants.Theme, string, error) {
+	if t, ok := constants.Themes[name]; ok {
+		return t, "", nil
+	}
+	var file struct {
+		Base   string           `toml:"base"`
+		Colors map[string]Color `toml:"colors"`
+	}
+	path := filepath.Join(dir, name+".toml")
+	md, err := toml.DecodeFile(path, &file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return constants.Theme{}, "", fmt.Errorf("unknown theme %q, expected one of: %s or a file %s", name, strings.Join(constants.ThemeNames(), ", "), path)
+	}
+	if err != nil {
+		return constants.Theme{}, path, fmt.Errorf("%s: %w", path, err)
+	}
+	if k, ok := unknownKey(md); ok {
+		return constants.Theme{}, path, fmt.Errorf("%s: unknown setting %q", path, k.String())
+	}
+	if file.Base == "" {
+		file.Base = "default"
+	}
+	base, ok := constants.Themes[file.Base]
+	if !ok {
+		return constants.Theme{}, path, fmt.Errorf("%s: unknown base theme %q, expected one of: %s", path, file.Base, strings.Join(constants.ThemeNames(), ", "))
+	}
+	colors := adaptive(file.Colors)
+	if err := constants.CheckColors(colors); err != nil {
+		return constants.Theme{}, path, fmt.Errorf("%s: %w", path, err)
+	}
+	return base.With(colors), path, nil
+}
+
+// DefaultPath returns config.toml in the tailscale-tui directory under the
+// XDG config dir.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tailscale-tui", "config.toml")
+}
+
+// unknownKey returns the first setting that was not decoded. Tables decoded
+// by Keys and Color are reported too, those are checked when decoding.
+func unknownKey(md toml.MetaData) (toml.Key, bool) {
+	for _, k := range md.Undecoded() {
+		if k[0] == "keys" || (k[0] == "colors" && len(k) > 2) {
+			continue
+		}
+		return k, true
+	}
+	return nil, false
+}
+
+// Load reads the config file at path, a missing file gives the defaults.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if k, ok := unknownKey(md); ok {
+		return cfg, fmt.Errorf("%s: unknown setting %q", path, k.String())
+	}
+	if cfg.Theme != "" {
+		cfg.palette, cfg.themeFile, err = loadTheme(filepath.Join(filepath.Dir(path), "themes"), cfg.Theme)
+	}
+	return cfg, err
+}
+
+func (c Config) Validate() error {
+	var errs []string
+	check := func(ok bool, format string, a ...any) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, a...))
+		}
+	}
+	check(c.Ping.Count >= 0, "ping.count must not be negative")
+	check(c.Ping.Interval >= 0, "ping.interval must not be negative")
+	check(c.Ping.Timeout > 0, "ping.timeout must be positive")
+	check(c.Probe.Concurrency > 0, "probe.concurrency must be positive")
+	check(c.Probe.Rate >= 0, "probe.rate must not be negative")
+	check(c.Probe.Interval > 0, "probe.interval must be positive")
+	check(c.Refresh.BusDelay >= 0, "refresh.bus_delay must not be negative")
+	check(c.Refresh.LoginPoll > 0, "refresh.login_poll must be positive")
+	check(c.Refresh.Traffic >= 0, "refresh.traffic must not be negative")
+	if _, err := ts.ParsePeerSort(c.List.Sort); err != nil {
+		errs = append(errs, "list.sort: "+err.Error())
+	}
+	if _, err := ts.ParsePeerGrouping(c.List.Group); err != nil {
+		errs = append(errs, "list.group: "+err.Error())
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Filters)) {
+		if _, err := ts.ParsePeerFilter(c.Filters[name]); err != nil {
+			errs = append(errs, fmt.Sprintf("filters.%s: %s", name, err))
+		}
+	}
+	check(slices.Contains([]string{"auto", "light", "dark"}, c.Background), "background must be auto, light or dark")
+	if err := constants.CheckColors(adaptive(c.Colors)); err != nil {
+		errs = append(errs, "colors: "+err.Error())
+	}
+	if err := keymap.CheckOverrides(c.keys()); err != nil {
+		errs = append(errs, "keys: "+err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c Config) keys() keymap.Overrides {
+	o := keymap.Overrides{"": {}}
+	for name, k := range c.Keys {
+		if k.View == nil {
+			o[""][name] = k.Keys
+			continue
+		}
+		o[name] = map[string][]string{}
+		for binding, keys := range k.View {
+			o[name][binding] = keys
+		}
+	}
+	return o
+}
+
+// Apply sets the theme and keys used by the views created afterwards.
+func (c Config) Apply() {
+	constants.SetBackground(c.Background)
+	constants.SetTheme(c.palette.With(adaptive(c.Colors)))
+	keymap.SetOverrides(c.keys())
+}
+
+func (c Config) PingOptions() ts.PingOptions {
+	return ts.PingOptions{
+		Count:    c.Ping.Count,
+		Interval: c.Ping.Interval,
+		Timeout:  c.Ping.Timeout,
+	}
+}
+
+// ProbeOptions returns the probe options, probes use the ping timeout.
+func (c Config) ProbeOptions() ts.ProbeOptions {
+	return ts.ProbeOptions{
+		Enabled:     c.Probe.Enabled,
+		Concurrency: c.Probe.Concurrency,
+		Rate:        c.Probe.Rate,
+		Interval:    c.Probe.Interval,
+		Timeout:     c.Ping.Timeout,
+	}
+}
+
+// PeerOrder returns the default order of the node list.
+func (c Config) PeerOrder() ts.PeerOrder {
+	by, _ := ts.ParsePeerSort(c.List.Sort)
+	return ts.PeerOrder{By: by, Descending: c.List.Descending}
+}
+
+// PeerGrouping returns the default grouping of the node list.
+func (c Config) PeerGrouping() ts.PeerGrouping {
+	g, _ := ts.ParsePeerGrouping(c.List.Group)
+	return g
+}
+
+// SavedFilters returns the saved filters sorted by name.
+func (c Config) SavedFilters() []ts.SavedFilter {
+	var filters []ts.SavedFilter
+	for _, name := range slices.Sorted(maps.Keys(c.Filters)) {
+		filters = append(filters, ts.SavedFilter{Name: name, Query: c.Filters[name]})
+	}
+	return filters
+}
+
+// Watcher reloads the config file, or the theme file in use, when it
+// changes. Override is applied to every config loaded, to keep the command
+// line flags on top of the file.
+type Watcher struct {
+	Path     string
+	Override func(*Config)
+
+	// mu guards the fields below, Load runs in a command while Changed is
+	// polled from Update.
+	mu        sync.Mutex
+	themeFile string
+	state     string
+}
+
+func NewWatcher(path string, override func(*Config)) *Watcher {
+	w := &Watcher{Path: path, Override: override}
+	w.Changed()
+	return w
+}
+
+// Changed reports whether the files changed since the last call.
+func (w *Watcher) Changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.changed()
+}
+
+func (w *Watcher) changed() bool {
+	var state strings.Builder
+	for _, path := range []string{w.Path, w.themeFile} {
+		if fi, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&state, "%s %d;", fi.ModTime(), fi.Size())
+		} else {
+			state.WriteString("-;")
+		}
+	}
+	changed := state.String() != w.state
+	w.state = state.String()
+	return changed
+}
+
+// Load loads and validates the config file with the overrides applied.
+func (w *Watcher) Load() (Config, error) {
+	cfg, err := Load(w.Path)
+	w.mu.Lock()
+	if cfg.themeFile != w.themeFile {
+		w.themeFile = cfg.themeFile
+		w.changed()
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return cfg, err
+	}
+	if w.Override != nil {
+		w.Override(&cfg)
+	}
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,61 @@
+package ts
+
+import (
+	"context"
+	"io"
+	"net/netip"
+
+	"tailscale.com/client/tailscale"
+	"tailscale.com/client/tailscale/apitype"
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/netcheck"
+	"tailscale.com/tailcfg"
+)
+
+// Backend is the subset of the tailscaled LocalAPI used by the TUI, plus
+// netcheck which runs in this process like it does for the CLI.
+// NewLocalBackend wraps *tailscale.LocalClient, FakeBackend is an in-memory
+// implementation that can be used without a running daemon.
+type Backend interface {
+	Status(ctx context.Context) (*ipnstate.Status, error)
+	GetPrefs(ctx context.Context) (*ipn.Prefs, error)
+	EditPrefs(ctx context.Context, mp *ipn.MaskedPrefs) (*ipn.Prefs, error)
+	Ping(ctx context.Context, ip netip.Addr, pingtype tailcfg.PingType) (*ipnstate.PingResult, error)
+	WatchIPNBus(ctx context.Context, mask ipn.NotifyWatchOpt) (BusWatcher, error)
+	ProfileStatus(ctx context.Context) (current ipn.LoginProfile, all []ipn.LoginProfile, err error)
+	SwitchProfile(ctx context.Context, profile ipn.ProfileID) error
+	DeleteProfile(ctx context.Context, profile ipn.ProfileID) error
+	SwitchToEmptyProfile(ctx context.Context) error
+	StartLoginInteractive(ctx context.Context) error
+	Logout(ctx context.Context) error
+	FileTargets(ctx context.Context) ([]apitype.FileTarget, error)
+	PushFile(ctx context.Context, target tailcfg.StableNodeID, size int64, name string, r io.Reader) error
+	WaitingFiles(ctx context.Context) ([]apitype.WaitingFile, error)
+	GetWaitingFile(ctx context.Context, baseName string) (io.ReadCloser, int64, error)
+	DeleteWaitingFile(ctx context.Context, baseName string) error
+	CurrentDERPMap(ctx context.Context) (*tailcfg.DERPMap, error)
+	Netcheck(ctx context.Context, dm *tailcfg.DERPMap) (*netcheck.Report, error)
+}
+
+// localBackend adapts *tailscale.LocalClient to Backend.
+type localBackend struct {
+	*tailscale.LocalClient
+}
+
+func (b localBackend) WatchIPNBus(ctx context.Context, mask ipn.NotifyWatchOpt) (BusWatcher, error) {
+	w, err := b.LocalClient.WatchIPNBus(ctx, mask)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (b localBackend) Netcheck(ctx context.Context, dm *tailcfg.DERPMap) (*netcheck.Report, error) {
+	return runNetcheck(ctx, dm)
+}
+
+// NewLocalBackend returns a Backend talking to the local tailscaled.
+func NewLocalBackend() Backend {
+	return localBackend{&tailscale.LocalClient{}}
+}
@@ -0,0 +1,31 @@
+package ts
+
+import (
+	"context"
+	"time"
+
+	"tailscale.com/ipn"
+)
+
+// BusWatcher is an active subscription to the IPN notification bus.
+type BusWatcher interface {
+	Next() (ipn.Notify, error)
+	Close() error
+}
+
+const (
+	BusMinBackoff = time.Second
+	busMaxBackoff = 30 * time.Second
+)
+
+// WatchBus subscribes to the IPN bus, starting with the current state and
+// prefs. The subscription ends when ctx is done.
+func WatchBus(ctx context.Context, b Backend) (BusWatcher, error) {
+	return b.WatchIPNBus(ctx, ipn.NotifyInitialState|ipn.NotifyInitialPrefs|ipn.NotifyNoPrivateKeys)
+}
+
+// NextBusBackoff returns the wait before the next retry when subscribing
+// failed again after waiting backoff.
+func NextBusBackoff(backoff time.Duration) time.Duration {
+	return min(max(backoff*2, BusMinBackoff), busMaxBackoff)
+}
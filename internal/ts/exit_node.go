@@ -0,0 +1,43 @@
+package ts
+
+import (
+	"context"
+	"net/netip"
+
+	"tailscale.com/ipn"
+	"tailscale.com/tailcfg"
+)
+
+// SetExitNode routes internet traffic through the peer with the given ID.
+// An empty id stops using an exit node.
+// Equivalent to `tailscale set --exit-node=<id>`
+func SetExitNode(b Backend, id tailcfg.StableNodeID) (*ipn.Prefs, error) {
+	return b.EditPrefs(context.Background(), &ipn.MaskedPrefs{
+		Prefs: ipn.Prefs{
+			ExitNodeID: id,
+		},
+		ExitNodeIDSet: true,
+		ExitNodeIPSet: true,
+	})
+}
+
+// Equivalent to `tailscale set --exit-node-allow-lan-access=<allow>`
+func SetExitNodeAllowLANAccess(b Backend, allow bool) (*ipn.Prefs, error) {
+	return b.EditPrefs(context.Background(), &ipn.MaskedPrefs{
+		Prefs: ipn.Prefs{
+			ExitNodeAllowLANAccess: allow,
+		},
+		ExitNodeAllowLANAccessSet: true,
+	})
+}
+
+// SetOfferExitNode adds or removes the 0.0.0.0/0 and ::/0 routes from the
+// advertised routes, leaving other advertised subnets untouched.
+// Equivalent to `tailscale set --advertise-exit-node=<offer>`
+func SetOfferExitNode(b Backend, offer bool) (*ipn.Prefs, error) {
+	return editRoutes(b, func(routes []netip.Prefix) ([]netip.Prefix, error) {
+		prefs := ipn.Prefs{AdvertiseRoutes: routes}
+		prefs.SetAdvertiseExitNode(offer)
+		return prefs.AdvertiseRoutes, nil
+	})
+}
@@ -0,0 +1,139 @@
+package ts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+type ExportFormat int
+
+const (
+	ExportMarkdown ExportFormat = iota
+	ExportCSV
+	ExportJSON
+)
+
+func (f ExportFormat) String() string {
+	return [...]string{
+		"markdown",
+		"CSV",
+		"JSON",
+	}[f]
+}
+
+func (f ExportFormat) Ext() string {
+	return [...]string{
+		".md",
+		".csv",
+		".json",
+	}[f]
+}
+
+// ExportFormatOf returns the format matching the extension of path.
+func ExportFormatOf(path string) (ExportFormat, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for f := ExportMarkdown; f <= ExportJSON; f++ {
+		if f.Ext() == ext {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// ExportedPeer holds the columns of an export, the fields shown in the node
+// details.
+type ExportedPeer struct {
+	Hostname  string   `json:"hostname"`
+	Owner     string   `json:"owner"`
+	OS        string   `json:"os"`
+	IPs       []string `json:"ips"`
+	DNSName   string   `json:"dns_name"`
+	Online    bool     `json:"online"`
+	ExitNode  string   `json:"exit_node"`
+	Relay     string   `json:"relay"`
+	KeyExpiry string   `json:"key_expiry"`
+}
+
+var exportHeader = []string{"Hostname", "Owner", "OS", "IPs", "DNS name", "Online", "Exit node", "Relay", "Key expiry"}
+
+func (p ExportedPeer) columns() []string {
+	return []string{p.Hostname, p.Owner, p.OS, strings.Join(p.IPs, " "), p.DNSName, strconv.FormatBool(p.Online), p.ExitNode, p.Relay, p.KeyExpiry}
+}
+
+func NewExportedPeer(status *ipnstate.Status, p *ipnstate.PeerStatus) ExportedPeer {
+	e := ExportedPeer{
+		Hostname:  p.HostName,
+		Owner:     OwnerLogin(status, p),
+		OS:        p.OS,
+		IPs:       []string{},
+		DNSName:   strings.TrimSuffix(p.DNSName, "."),
+		Online:    p.Online,
+		Relay:     p.Relay,
+		KeyExpiry: "disabled",
+	}
+	for _, ip := range p.TailscaleIPs {
+		e.IPs = append(e.IPs, ip.String())
+	}
+	switch {
+	case p.ExitNode:
+		e.ExitNode = "in use"
+	case p.ExitNodeOption:
+		e.ExitNode = "offered"
+	}
+	if p.KeyExpiry != nil {
+		e.KeyExpiry = p.KeyExpiry.UTC().Format(time.RFC3339)
+		if p.Expired {
+			e.KeyExpiry += " (expired)"
+		}
+	}
+	return e
+}
+
+// ExportPeers formats peers as a JSON array, a CSV file with a header or a
+// Markdown table.
+func ExportPeers(status *ipnstate.Status, peers []*ipnstate.PeerStatus, format ExportFormat) ([]byte, error) {
+	exported := []ExportedPeer{}
+	for _, p := range peers {
+		exported = append(exported, NewExportedPeer(status, p))
+	}
+	var buf bytes.Buffer
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(exported); err != nil {
+			return nil, err
+		}
+	case ExportCSV:
+		w := csv.NewWriter(&buf)
+		w.Write(exportHeader)
+		for _, p := range exported {
+			w.Write(p.columns())
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	case ExportMarkdown:
+		row := func(cells []string) {
+			for i, c := range cells {
+				cells[i] = strings.ReplaceAll(c, "|", `\|`)
+			}
+			fmt.Fprintf(&buf, "| %s |\n", strings.Join(cells, " | "))
+		}
+		row(append([]string(nil), exportHeader...))
+		row(strings.Split(strings.Repeat("---,", len(exportHeader)-1)+"---", ","))
+		for _, p := range exported {
+			row(p.columns())
+		}
+	}
+	return buf.Bytes(), nil
+}
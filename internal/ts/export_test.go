@@ -0,0 +1,84 @@
+package ts
+
+import (
+	"testing"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+func TestExportPeers(t *testing.T) {
+	status := FakeStatus()
+	peers := []*ipnstate.PeerStatus{peerByName(status, "exit"), peerByName(status, "ci")}
+	tests := []struct {
+		format ExportFormat
+		want   string
+	}{
+		{ExportCSV, `Hostname,Owner,OS,IPs,DNS name,Online,Exit node,Relay,Key expiry
+exit,me@example.com,linux,100.64.0.3,exit.tailnet.ts.net,true,offered,,disabled
+ci,tagged device,windows,100.64.0.5,ci.tailnet.ts.net,true,,,2030-01-01T00:00:00Z
+`},
+		{ExportMarkdown, `| Hostname | Owner | OS | IPs | DNS name | Online | Exit node | Relay | Key expiry |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| exit | me@example.com | linux | 100.64.0.3 | exit.tailnet.ts.net | true | offered |  | disabled |
+| ci | tagged device | windows | 100.64.0.5 | ci.tailnet.ts.net | true |  |  | 2030-01-01T00:00:00Z |
+`},
+		{ExportJSON, `[
+  {
+    "hostname": "exit",
+    "owner": "me@example.com",
+    "os": "linux",
+    "ips": [
+      "100.64.0.3"
+    ],
+    "dns_name": "exit.tailnet.ts.net",
+    "online": true,
+    "exit_node": "offered",
+    "relay": "",
+    "key_expiry": "disabled"
+  },
+  {
+    "hostname": "ci",
+    "owner": "tagged device",
+    "os": "windows",
+    "ips": [
+      "100.64.0.5"
+    ],
+    "dns_name": "ci.tailnet.ts.net",
+    "online": true,
+    "exit_node": "",
+    "relay": "",
+    "key_expiry": "2030-01-01T00:00:00Z"
+  }
+]
+`},
+	}
+	for _, tt := range tests {
+		got, err := ExportPeers(status, peers, tt.format)
+		if err != nil {
+			t.Errorf("ExportPeers(%s) error = %v", tt.format, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("ExportPeers(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestExportFormatOf(t *testing.T) {
+	tests := []struct {
+		path string
+		want ExportFormat
+		ok   bool
+	}{
+		{"peers.md", ExportMarkdown, true},
+		{"peers.CSV", ExportCSV, true},
+		{"/tmp/peers.json", ExportJSON, true},
+		{"peers.txt", 0, false},
+		{"peers", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := ExportFormatOf(tt.path); got != tt.want || ok != tt.ok {
+			t.Errorf("ExportFormatOf(%q) = %v, %t, want %v, %t", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+package ts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"tailscale.com/client/tailscale/apitype"
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/netcheck"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/empty"
+	"tailscale.com/types/key"
+	"tailscale.com/types/views"
+)
+
+// FakePing is a scripted reply returned by FakeBackend.Ping.
+type FakePing struct {
+	Result *ipnstate.PingResult
+	Err    error
+	Delay  time.Duration
+}
+
+// FakeBackend is an in-memory Backend serving canned status values and
+// scripted ping results.
+type FakeBackend struct {
+	mu        sync.Mutex
+	status    *ipnstate.Status
+	statusErr error
+	prefs     *ipn.Prefs
+	pings     map[netip.Addr][]FakePing
+	edits     []*ipn.MaskedPrefs
+	watchers  map[*fakeWatcher]bool
+	profiles  []ipn.LoginProfile
+	profile   ipn.ProfileID
+	targets   map[tailcfg.StableNodeID]bool
+	pushed    []string
+	waiting   map[string][]byte
+	derpMap   *tailcfg.DERPMap
+	netcheck  *netcheck.Report
+}
+
+var _ Backend = (*FakeBackend)(nil)
+
+// FakeStatus returns a running tailnet of this device, laptop, and four
+// peers: server, routing 10.0.0.0/24, exit, offering to be an exit node,
+// phone, offline and shared by alice, and ci, tagged tag:server.
+func FakeStatus() *ipnstate.Status {
+	status := &ipnstate.Status{
+		BackendState: ipn.Running.String(),
+		Peer:         map[key.NodePublic]*ipnstate.PeerStatus{},
+		User: map[tailcfg.UserID]tailcfg.UserProfile{
+			1: {LoginName: "me@example.com", DisplayName: "Me"},
+			2: {LoginName: "alice@example.com", DisplayName: "Alice"},
+		},
+	}
+	routes := views.SliceOf([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")})
+	tags := views.SliceOf([]string{"tag:server"})
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, h := range []string{"laptop", "server", "exit", "phone", "ci"} {
+		p := &ipnstate.PeerStatus{
+			ID:           tailcfg.StableNodeID(h),
+			PublicKey:    key.NewNode().Public(),
+			HostName:     h,
+			DNSName:      h + ".tailnet.ts.net.",
+			OS:           "linux",
+			UserID:       1,
+			Online:       true,
+			TailscaleIPs: []netip.Addr{netip.AddrFrom4([4]byte{100, 64, 0, byte(i + 1)})},
+		}
+		switch h {
+		case "laptop":
+			status.Self = p
+			continue
+		case "server":
+			p.PrimaryRoutes = &routes
+			p.RxBytes, p.TxBytes = 3000, 1000
+		case "exit":
+			p.ExitNodeOption = true
+			p.RxBytes, p.TxBytes = 500, 500
+		case "phone":
+			p.OS, p.UserID, p.Online = "android", 2, false
+			p.LastSeen = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		case "ci":
+			p.OS, p.UserID, p.Tags, p.KeyExpiry = "windows", 2, &tags, &expiry
+		}
+		status.Peer[p.PublicKey] = p
+	}
+	return status
+}
+
+func NewFakeBackend(status *ipnstate.Status) *FakeBackend {
+	prefs := ipn.NewPrefs()
+	if status != nil {
+		prefs.WantRunning = status.BackendState == ipn.Running.String()
+	}
+	return &FakeBackend{
+		status:   cloneStatus(status),
+		prefs:    prefs,
+		pings:    map[netip.Addr][]FakePing{},
+		watchers: map[*fakeWatcher]bool{},
+		targets:  map[tailcfg.StableNodeID]bool{},
+		waiting:  map[string][]byte{},
+	}
+}
+
+func (f *FakeBackend) SetStatus(status *ipnstate.Status, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = cloneStatus(status)
+	f.statusErr = err
+}
+
+func (f *FakeBackend) SetPrefs(prefs *ipn.Prefs) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prefs = prefs.Clone()
+}
+
+// QueuePing appends replies to be returned, in order, by pings to ip.
+func (f *FakeBackend) QueuePing(ip netip.Addr, replies ...FakePing) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pings[ip] = append(f.pings[ip], replies...)
+}
+
+// Edits returns every MaskedPrefs passed to EditPrefs so far.
+func (f *FakeBackend) Edits() []*ipn.MaskedPrefs {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*ipn.MaskedPrefs(nil), f.edits...)
+}
+
+func (f *FakeBackend) Status(ctx context.Context) (*ipnstate.Status, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.statusErr != nil {
+		return nil, f.statusErr
+	}
+	if f.status == nil {
+		return nil, errors.New("fake: no status")
+	}
+	return cloneStatus(f.status), nil
+}
+
+// cloneStatus copies status and its peers, so that the changes made by the
+// fake only reach the models through Status.
+func cloneStatus(status *ipnstate.Status) *ipnstate.Status {
+	if status == nil {
+		return nil
+	}
+	c := *status
+	clonePeer := func(p *ipnstate.PeerStatus) *ipnstate.PeerStatus {
+		cp := *p
+		cp.TailscaleIPs = slices.Clone(p.TailscaleIPs)
+		return &cp
+	}
+	if status.Self != nil {
+		c.Self = clonePeer(status.Self)
+	}
+	c.Peer = make(map[key.NodePublic]*ipnstate.PeerStatus, len(status.Peer))
+	for k, p := range status.Peer {
+		c.Peer[k] = clonePeer(p)
+	}
+	c.User = maps.Clone(status.User)
+	if status.ExitNodeStatus != nil {
+		e := *status.ExitNodeStatus
+		c.ExitNodeStatus = &e
+	}
+	return &c
+}
+
+func (f *FakeBackend) GetPrefs(ctx context.Context) (*ipn.Prefs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.prefs.Clone(), nil
+}
+
+func (f *FakeBackend) EditPrefs(ctx context.Context, mp *ipn.MaskedPrefs) (*ipn.Prefs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edits = append(f.edits, mp)
+	f.prefs.ApplyEdits(mp)
+	if mp.WantRunningSet && f.status != nil {
+		f.status.BackendState = ipn.Stopped.String()
+		if mp.WantRunning {
+			f.status.BackendState = ipn.Running.String()
+		}
+		if f.status.Self != nil {
+			f.status.Self.Online = mp.WantRunning
+		}
+		state := ipn.Stopped
+		if mp.WantRunning {
+			state = ipn.Running
+		}
+		f.publishLocked(ipn.Notify{State: &state})
+	}
+	if mp.ExitNodeIDSet && f.status != nil {
+		f.status.ExitNodeStatus = nil
+		for _, peer := range f.status.Peer {
+			peer.ExitNode = peer.ID != "" && peer.ID == mp.ExitNodeID
+			if peer.ExitNode {
+				f.status.ExitNodeStatus = &ipnstate.ExitNodeStatus{ID: peer.ID, Online: peer.Online}
+			}
+		}
+	}
+	pv := f.prefs.Clone().View()
+	f.publishLocked(ipn.Notify{Prefs: &pv})
+	return f.prefs.Clone(), nil
+}
+
+func (f *FakeBackend) Ping(ctx context.Context, ip netip.Addr, pingtype tailcfg.PingType) (*ipnstate.PingResult, error) {
+	f.mu.Lock()
+	queue := f.pings[ip]
+	if len(queue) == 0 {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("fake: no scripted ping for %s", ip)
+	}
+	reply := queue[0]
+	f.pings[ip] = queue[1:]
+	f.mu.Unlock()
+
+	if reply.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(reply.Delay):
+		}
+	}
+	return reply.Result, reply.Err
+}
+
+func (f *FakeBackend) WatchIPNBus(ctx context.Context, mask ipn.NotifyWatchOpt) (BusWatcher, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWatcher{ctx: ctx, ch: make(chan ipn.Notify, 16), done: make(chan struct{})}
+	f.watchers[w] = true
+	if mask&ipn.NotifyInitialState != 0 && f.status != nil {
+		state := ipn.Running
+		for s := ipn.NoState; s <= ipn.Running; s++ {
+			if s.String() == f.status.BackendState {
+				state = s
+			}
+		}
+		w.ch <- ipn.Notify{State: &state}
+	}
+	if mask&ipn.NotifyInitialPrefs != 0 {
+		pv := f.prefs.Clone().View()
+		w.ch <- ipn.Notify{Prefs: &pv}
+	}
+	return w, nil
+}
+
+// Publish sends n to every open IPN bus watcher.
+func (f *FakeBackend) Publish(n ipn.Notify) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.publishLocked(n)
+}
+
+// DropWatchers ends every open IPN bus watcher with an error, as if
+// tailscaled had restarted.
+func (f *FakeBackend) DropWatchers() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for w := range f.watchers {
+		w.Close()
+		delete(f.watchers, w)
+	}
+}
+
+func (f *FakeBackend) publishLocked(n ipn.Notify) {
+	for w := range f.watchers {
+		select {
+		case w.ch <- n:
+		case <-w.done:
+			delete(f.watchers, w)
+		default:
+		}
+	}
+}
+
+type fakeWatcher struct {
+	ctx       context.Context
+	ch        chan ipn.Notify
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (w *fakeWatcher) Next() (ipn.Notify, error) {
+	select {
+	case n := <-w.ch:
+		return n, nil
+	case <-w.done:
+		return ipn.Notify{}, errors.New("fake: watcher closed")
+	case <-w.ctx.Done():
+		return ipn.Notify{}, w.ctx.Err()
+	}
+}
+
+func (w *fakeWatcher) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	return nil
+}
+
+// SetProfiles replaces the login profiles, making current the active one.
+func (f *FakeBackend) SetProfiles(current ipn.ProfileID, all ...ipn.LoginProfile) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profiles = slices.Clone(all)
+	f.profile = current
+}
+
+func (f *FakeBackend) ProfileStatus(ctx context.Context) (ipn.LoginProfile, []ipn.LoginProfile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var current ipn.LoginProfile
+	for _, p := range f.profiles {
+		if p.ID == f.profile {
+			current = p
+		}
+	}
+	return current, slices.Clone(f.profiles), nil
+}
+
+func (f *FakeBackend) SwitchProfile(ctx context.Context, profile ipn.ProfileID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !slices.ContainsFunc(f.profiles, func(p ipn.LoginProfile) bool { return p.ID == profile }) {
+		return fmt.Errorf("fake: profile %q not found", profile)
+	}
+	f.profile = profile
+	return nil
+}
+
+func (f *FakeBackend) DeleteProfile(ctx context.Context, profile ipn.ProfileID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.profiles, func(p ipn.LoginProfile) bool { return p.ID == profile })
+	if i < 0 {
+		return fmt.Errorf("fake: profile %q not found", profile)
+	}
+	f.profiles = slices.Delete(f.profiles, i, i+1)
+	if f.profile == profile {
+		f.profile = ""
+	}
+	return nil
+}
+
+func (f *FakeBackend) SwitchToEmptyProfile(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profile = ""
+	if f.status != nil {
+		f.status.BackendState = ipn.NeedsLogin.String()
+	}
+	return nil
+}
+
+// FakeAuthURL is the login URL handed out by FakeBackend.StartLoginInteractive.
+const FakeAuthURL = "https://login.tailscale.com/a/fake"
+
+func (f *FakeBackend) StartLoginInteractive(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.status == nil {
+		return errors.New("fake: no status")
+	}
+	f.status.AuthURL = FakeAuthURL
+	url := FakeAuthURL
+	f.publishLocked(ipn.Notify{BrowseToURL: &url})
+	return nil
+}
+
+// CompleteLogin simulates the user finishing the login in a browser.
+func (f *FakeBackend) CompleteLogin() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := ipn.Running
+	if f.status != nil {
+		f.status.AuthURL = ""
+		f.status.BackendState = state.String()
+	}
+	f.publishLocked(ipn.Notify{LoginFinished: &empty.Message{}, State: &state})
+}
+
+func (f *FakeBackend) Logout(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := ipn.NeedsLogin
+	if f.status != nil {
+		f.status.BackendState = state.String()
+	}
+	f.publishLocked(ipn.Notify{State: &state})
+	return nil
+}
+
+// SetFileTargets sets the peers accepted as Taildrop targets.
+func (f *FakeBackend) SetFileTargets(ids ...tailcfg.StableNodeID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.targets = map[tailcfg.StableNodeID]bool{}
+	for _, id := range ids {
+		f.targets[id] = true
+	}
+}
+
+// Pushed returns the names of the files fully received by PushFile.
+func (f *FakeBackend) Pushed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.pushed)
+}
+
+func (f *FakeBackend) FileTargets(ctx context.Context) ([]apitype.FileTarget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var targets []apitype.FileTarget
+	if f.status == nil {
+		return targets, nil
+	}
+	for _, peer := range f.status.Peer {
+		if !f.targets[peer.ID] {
+			continue
+		}
+		node := &tailcfg.Node{StableID: peer.ID, Name: peer.DNSName, ComputedName: peer.HostName}
+		url := ""
+		if len(peer.TailscaleIPs) > 0 {
+			url = fmt.Sprintf("http://%s:12345", peer.TailscaleIPs[0])
+		}
+		targets = append(targets, apitype.FileTarget{Node: node, PeerAPIURL: url})
+	}
+	return targets, nil
+}
+
+// PushFile reads r in small chunks at a throttled pace so progress can be
+// observed.
+func (f *FakeBackend) PushFile(ctx context.Context, target tailcfg.StableNodeID, size int64, name string, r io.Reader) error {
+	f.mu.Lock()
+	ok := f.targets[target]
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s is not a file target", target)
+	}
+	buf := make([]byte, 64<<10)
+	var n int64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+		m, err := r.Read(buf)
+		n += int64(m)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if n != size {
+		return fmt.Errorf("short read: got %d of %d bytes", n, size)
+	}
+	f.mu.Lock()
+	f.pushed = append(f.pushed, name)
+	f.mu.Unlock()
+	return nil
+}
+
+// ReceiveFile adds a file to the Taildrop inbox and notifies watchers.
+func (f *FakeBackend) ReceiveFile(name string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waiting[name] = data
+	f.publishLocked(ipn.Notify{FilesWaiting: &empty.Message{}})
+}
+
+func (f *FakeBackend) WaitingFiles(ctx context.Context) ([]apitype.WaitingFile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	files := []apitype.WaitingFile{}
+	for name, data := range f.waiting {
+		files = append(files, apitype.WaitingFile{Name: name, Size: int64(len(data))})
+	}
+	slices.SortFunc(files, func(a, b apitype.WaitingFile) int { return strings.Compare(a.Name, b.Name) })
+	return files, nil
+}
+
+func (f *FakeBackend) GetWaitingFile(ctx context.Context, baseName string) (io.ReadCloser, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.waiting[baseName]
+	if !ok {
+		return nil, 0, fmt.Errorf("%s: %w", baseName, os.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+func (f *FakeBackend) DeleteWaitingFile(ctx context.Context, baseName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.waiting[baseName]; !ok {
+		return fmt.Errorf("%s: %w", baseName, os.ErrNotExist)
+	}
+	delete(f.waiting, baseName)
+	return nil
+}
+
+// SetNetcheck sets the DERP map and the report returned by Netcheck.
+func (f *FakeBackend) SetNetcheck(dm *tailcfg.DERPMap, report *netcheck.Report) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.derpMap = dm
+	f.netcheck = report
+}
+
+func (f *FakeBackend) CurrentDERPMap(ctx context.Context) (*tailcfg.DERPMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.derpMap, nil
+}
+
+func (f *FakeBackend) Netcheck(ctx context.Context, dm *tailcfg.DERPMap) (*netcheck.Report, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.netcheck == nil {
+		return nil, errors.New("netcheck: no report")
+	}
+	return f.netcheck.Clone(), nil
+}
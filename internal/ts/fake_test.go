@@ -0,0 +1,20 @@
+package ts
+
+import "tailscale.com/ipn/ipnstate"
+
+func hostNames(peers []*ipnstate.PeerStatus) []string {
+	var names []string
+	for _, p := range peers {
+		names = append(names, p.HostName)
+	}
+	return names
+}
+
+func peerByName(status *ipnstate.Status, name string) *ipnstate.PeerStatus {
+	for _, p := range status.Peer {
+		if p.HostName == name {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package ts
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+// PeerFilter is a parsed filter query like "online:true -os:windows web".
+// Words without a field are free text.
+type PeerFilter struct {
+	terms []filterTerm
+	words []string
+}
+
+type peerMatch func(status *ipnstate.Status, p *ipnstate.PeerStatus, now time.Time) bool
+
+type filterTerm struct {
+	negate bool
+	match  peerMatch
+}
+
+var filterFields = map[string]func(value string) (peerMatch, error){
+	"online": func(value string) (peerMatch, error) {
+		online, err := strconv.ParseBool(value)
+		return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+			return p.Online == online
+		}, err
+	},
+	"os": func(value string) (peerMatch, error) {
+		return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+			return strings.EqualFold(p.OS, value)
+		}, nil
+	},
+	"tag": func(value string) (peerMatch, error) {
+		if !strings.HasPrefix(value, "tag:") {
+			value = "tag:" + value
+		}
+		return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+			return p.Tags != nil && slices.ContainsFunc(p.Tags.AsSlice(), func(tag string) bool {
+				return strings.EqualFold(tag, value)
+			})
+		}, nil
+	},
+	"user": func(value string) (peerMatch, error) {
+		value = strings.ToLower(value)
+		return func(status *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+			u := status.User[p.UserID]
+			return strings.Contains(strings.ToLower(u.LoginName), value) ||
+				strings.Contains(strings.ToLower(u.DisplayName), value)
+		}, nil
+	},
+	"exitnode": func(value string) (peerMatch, error) {
+		switch value {
+		case "offer":
+			return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+				return p.ExitNodeOption
+			}, nil
+		case "active":
+			return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+				return p.ExitNode
+			}, nil
+		}
+		return nil, fmt.Errorf("expected offer or active, got %q", value)
+	},
+	"route": func(value string) (peerMatch, error) {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(value)
+			if aerr != nil {
+				return nil, fmt.Errorf("expected a subnet or an IP address, got %q", value)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+			return p.PrimaryRoutes != nil && slices.ContainsFunc(p.PrimaryRoutes.AsSlice(), prefix.Overlaps)
+		}, nil
+	},
+	"expired": func(value string) (peerMatch, error) {
+		expired, err := strconv.ParseBool(value)
+		return func(_ *ipnstate.Status, p *ipnstate.PeerStatus, now time.Time) bool {
+			return (p.Expired || p.KeyExpiry != nil && p.KeyExpiry.Before(now)) == expired
+		}, err
+	},
+}
+
+// FilterFields returns the fields of a filter query.
+func FilterFields() []string {
+	return []string{"online", "os", "tag", "user", "exitnode", "route", "expired"}
+}
+
+var fieldName = regexp.MustCompile(`^[a-z]+$`)
+
+// ParsePeerFilter parses a filter query. Words with a field name before a
+// colon match that field, a leading - negates a word.
+func ParsePeerFilter(query string) (PeerFilter, error) {
+	var f PeerFilter
+	for _, word := range strings.Fields(query) {
+		negate := len(word) > 1 && word[0] == '-'
+		if negate {
+			word = word[1:]
+		}
+		field, value, ok := strings.Cut(word, ":")
+		if !ok || !fieldName.MatchString(field) {
+			if negate {
+				f.terms = append(f.terms, filterTerm{negate: true, match: textMatcher(word)})
+			} else {
+				f.words = append(f.words, word)
+			}
+			continue
+		}
+		parse, ok := filterFields[field]
+		if !ok {
+			return PeerFilter{}, fmt.Errorf("unknown field %q, expected one of: %s", field, strings.Join(FilterFields(), ", "))
+		}
+		if value == "" {
+			return PeerFilter{}, fmt.Errorf("%s: missing value", field)
+		}
+		match, err := parse(value)
+		if err != nil {
+			if _, ok := err.(*strconv.NumError); ok {
+				err = fmt.Errorf("expected true or false, got %q", value)
+			}
+			return PeerFilter{}, fmt.Errorf("%s: %w", field, err)
+		}
+		f.terms = append(f.terms, filterTerm{negate: negate, match: match})
+	}
+	return f, nil
+}
+
+// textMatcher matches the words of the device shown in the node list.
+func textMatcher(word string) peerMatch {
+	word = strings.ToLower(word)
+	return func(status *ipnstate.Status, p *ipnstate.PeerStatus, _ time.Time) bool {
+		text := []string{p.HostName, p.DNSName, p.OS, status.User[p.UserID].LoginName}
+		for _, ip := range p.TailscaleIPs {
+			text = append(text, ip.String())
+		}
+		return strings.Contains(strings.ToLower(strings.Join(text, " ")), word)
+	}
+}
+
+// Text returns the free text of the query, without the negated words.
+func (f PeerFilter) Text() string {
+	return strings.Join(f.words, " ")
+}
+
+// MatchFields reports whether p matches the fields and negated words of the
+// query, for callers matching the free text themselves.
+func (f PeerFilter) MatchFields(status *ipnstate.Status, p *ipnstate.PeerStatus) bool {
+	now := time.Now()
+	for _, t := range f.terms {
+		if t.match(status, p, now) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether p matches the query, free text words are looked up
+// in the host name, DNS name, OS, owner and IPs.
+func (f PeerFilter) Match(status *ipnstate.Status, p *ipnstate.PeerStatus) bool {
+	for _, word := range f.words {
+		if !textMatcher(word)(status, p, time.Time{}) {
+			return false
+		}
+	}
+	return f.MatchFields(status, p)
+}
+
+// SavedFilter is a filter query saved under a name in the config file.
+type SavedFilter struct {
+	Name  string
+	Query string
+}
@@ -0,0 +1,73 @@
+package ts
+
+import (
+	"slices"
+	"testing"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+func TestParsePeerFilter(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+		err   string
+	}{
+		{query: "", want: []string{"laptop", "exit", "server", "ci", "phone"}},
+		{query: "online:true", want: []string{"laptop", "exit", "server", "ci"}},
+		{query: "-online:true", want: []string{"phone"}},
+		{query: "os:Windows", want: []string{"ci"}},
+		{query: "tag:server", want: []string{"ci"}},
+		{query: "tag:tag:server", want: []string{"ci"}},
+		{query: "user:alice", want: []string{"ci", "phone"}},
+		{query: "exitnode:offer", want: []string{"exit"}},
+		{query: "exitnode:active"},
+		{query: "route:10.0.0.7", want: []string{"server"}},
+		{query: "route:10.0.0.0/16", want: []string{"server"}},
+		{query: "route:192.168.0.0/24"},
+		{query: "expired:false", want: []string{"laptop", "exit", "server", "ci", "phone"}},
+		{query: "serv", want: []string{"server"}},
+		{query: "100.64.0.4", want: []string{"phone"}},
+		{query: "linux -ser", want: []string{"laptop", "exit"}},
+		{query: "online:true -os:linux", want: []string{"ci"}},
+		{query: "foo:bar", err: `unknown field "foo", expected one of: online, os, tag, user, exitnode, route, expired`},
+		{query: "os:", err: "os: missing value"},
+		{query: "online:maybe", err: `online: expected true or false, got "maybe"`},
+		{query: "exitnode:yes", err: `exitnode: expected offer or active, got "yes"`},
+		{query: "route:lan", err: `route: expected a subnet or an IP address, got "lan"`},
+	}
+	status := FakeStatus()
+	for _, tt := range tests {
+		f, err := ParsePeerFilter(tt.query)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("ParsePeerFilter(%q) error = %v, want %q", tt.query, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePeerFilter(%q) error = %v", tt.query, err)
+			continue
+		}
+		got := slices.DeleteFunc(SortedPeers(status), func(p *ipnstate.PeerStatus) bool { return !f.Match(status, p) })
+		if names := hostNames(got); !slices.Equal(names, tt.want) {
+			t.Errorf("ParsePeerFilter(%q) matches %v, want %v", tt.query, names, tt.want)
+		}
+	}
+}
+
+func TestPeerFilterText(t *testing.T) {
+	f, err := ParsePeerFilter("web os:linux -db  eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Text(); got != "web eu" {
+		t.Errorf("Text() = %q, want %q", got, "web eu")
+	}
+	status := FakeStatus()
+	for _, p := range SortedPeers(status) {
+		if want := p.OS == "linux"; f.MatchFields(status, p) != want {
+			t.Errorf("MatchFields(%s) = %t, want %t", p.HostName, !want, want)
+		}
+	}
+}
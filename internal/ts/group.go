@@ -0,0 +1,122 @@
+package ts
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+// PeerGrouping is how the node list is split into sections.
+type PeerGrouping int
+
+const (
+	GroupNone PeerGrouping = iota
+	GroupOwner
+	GroupTag
+	GroupOS
+	GroupOnline
+	GroupSharing
+)
+
+func (g PeerGrouping) String() string {
+	return [...]string{
+		"none",
+		"owner",
+		"tag",
+		"OS",
+		"online",
+		"sharing",
+	}[g]
+}
+
+// Name is the name of g in the config file.
+func (g PeerGrouping) Name() string {
+	return strings.ReplaceAll(strings.ToLower(g.String()), " ", "_")
+}
+
+func ParsePeerGrouping(name string) (PeerGrouping, error) {
+	var names []string
+	for g := GroupNone; g <= GroupSharing; g++ {
+		if g.Name() == name {
+			return g, nil
+		}
+		names = append(names, g.Name())
+	}
+	return 0, fmt.Errorf("unknown grouping %q, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// PeerGroup is a section of the node list.
+type PeerGroup struct {
+	Name   string
+	Peers  []*ipnstate.PeerStatus
+	Online int
+}
+
+// groupNames returns the groups of p with a rank, groups of a lower rank
+// go first and the others by name.
+func groupNames(status *ipnstate.Status, p *ipnstate.PeerStatus, by PeerGrouping) ([]string, int) {
+	switch by {
+	case GroupOwner:
+		if IsTagged(p) {
+			return []string{"tagged devices"}, 2
+		}
+		if p.UserID == status.Self.UserID {
+			return []string{status.User[p.UserID].LoginName}, 0
+		}
+		return []string{status.User[p.UserID].LoginName}, 1
+	case GroupTag:
+		if !IsTagged(p) {
+			return []string{"untagged"}, 1
+		}
+		return p.Tags.AsSlice(), 0
+	case GroupOS:
+		if p.OS == "" {
+			return []string{"unknown OS"}, 1
+		}
+		return []string{p.OS}, 0
+	case GroupOnline:
+		if p.Online {
+			return []string{"online"}, 0
+		}
+		return []string{"offline"}, 1
+	case GroupSharing:
+		if p.UserID == status.Self.UserID {
+			return []string{"own devices"}, 0
+		}
+		return []string{"shared in"}, 1
+	}
+	return []string{""}, 0
+}
+
+// GroupPeers splits peers into groups, keeping their order in each group.
+// Devices with several tags are in the group of each tag.
+func GroupPeers(status *ipnstate.Status, peers []*ipnstate.PeerStatus, by PeerGrouping) []PeerGroup {
+	var groups []PeerGroup
+	index := map[string]int{}
+	rank := map[string]int{}
+	for _, p := range peers {
+		names, r := groupNames(status, p, by)
+		for _, name := range names {
+			i, ok := index[name]
+			if !ok {
+				i = len(groups)
+				index[name], rank[name] = i, r
+				groups = append(groups, PeerGroup{Name: name})
+			}
+			groups[i].Peers = append(groups[i].Peers, p)
+			if p.Online {
+				groups[i].Online++
+			}
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b PeerGroup) int {
+		if c := cmp.Compare(rank[a.Name], rank[b.Name]); c != 0 {
+			return c
+		}
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return groups
+}
@@ -0,0 +1,27 @@
+package ts
+
+import (
+	"context"
+
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+)
+
+// Equivalent to `tailscale login`
+func StartLogin(b Backend) error {
+	return b.StartLoginInteractive(context.Background())
+}
+
+// Equivalent to `tailscale logout`
+func Logout(b Backend) error {
+	return b.Logout(context.Background())
+}
+
+// NeedsLogin reports whether the backend is waiting for the user to log in
+// or for the device to be approved by an admin.
+func NeedsLogin(status *ipnstate.Status) bool {
+	if status == nil {
+		return false
+	}
+	return status.BackendState == ipn.NeedsLogin.String() || status.BackendState == ipn.NeedsMachineAuth.String()
+}
@@ -0,0 +1,162 @@
+package ts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/netcheck"
+	"tailscale.com/net/netmon"
+	"tailscale.com/net/portmapper"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/logger"
+)
+
+// NetcheckReport is a netcheck report together with the DERP map it was
+// run against.
+type NetcheckReport struct {
+	*netcheck.Report
+	DERPMap *tailcfg.DERPMap
+}
+
+// DERPRegion is the latency to a single DERP region.
+type DERPRegion struct {
+	ID        int
+	Code      string
+	Name      string
+	Latency   time.Duration // zero if the region did not answer
+	V4        time.Duration
+	V6        time.Duration
+	Preferred bool
+	Home      bool // used as home relay by this device
+	Peers     int  // peers using the region as their home relay
+}
+
+// runNetcheck runs a netcheck from this process, the same way the
+// tailscale CLI does.
+func runNetcheck(ctx context.Context, dm *tailcfg.DERPMap) (*netcheck.Report, error) {
+	netMon, err := netmon.New(logger.Discard)
+	if err != nil {
+		return nil, err
+	}
+	defer netMon.Close()
+	// Closing the portmapper releases any mappings created by the check.
+	pm := portmapper.NewClient(logger.Discard, netMon, nil, nil, nil)
+	defer pm.Close()
+	c := &netcheck.Client{
+		NetMon:     netMon,
+		PortMapper: pm,
+		Logf:       logger.Discard,
+	}
+	// A failed UDP test is reflected in the report.
+	c.Standalone(ctx, "")
+	return c.GetReport(ctx, dm, nil)
+}
+
+// Equivalent to `tailscale netcheck`
+func Netcheck(b Backend) (*NetcheckReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	dm, err := b.CurrentDERPMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dm == nil || len(dm.Regions) == 0 {
+		return nil, errors.New("no DERP map from tailscaled, is it logged in?")
+	}
+	report, err := b.Netcheck(ctx, dm)
+	if err != nil {
+		return nil, err
+	}
+	return &NetcheckReport{Report: report, DERPMap: dm}, nil
+}
+
+// DERPRegions returns every region of the DERP map, fastest first.
+// Regions that did not answer come last.
+func DERPRegions(r *NetcheckReport, status *ipnstate.Status) []DERPRegion {
+	peers := map[string]int{}
+	home := ""
+	if status != nil {
+		for _, peer := range status.Peer {
+			if peer.Relay != "" {
+				peers[peer.Relay]++
+			}
+		}
+		if status.Self != nil {
+			home = status.Self.Relay
+		}
+	}
+	var regions []DERPRegion
+	for id, region := range r.DERPMap.Regions {
+		regions = append(regions, DERPRegion{
+			ID:        id,
+			Code:      region.RegionCode,
+			Name:      region.RegionName,
+			Latency:   r.RegionLatency[id],
+			V4:        r.RegionV4Latency[id],
+			V6:        r.RegionV6Latency[id],
+			Preferred: id == r.PreferredDERP,
+			Home:      region.RegionCode == home,
+			Peers:     peers[region.RegionCode],
+		})
+	}
+	slices.SortFunc(regions, func(a, b DERPRegion) int {
+		if (a.Latency == 0) != (b.Latency == 0) {
+			if a.Latency == 0 {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(cmp.Compare(a.Latency, b.Latency), cmp.Compare(a.ID, b.ID))
+	})
+	return regions
+}
+
+// PreferredRegionName returns the name of the nearest DERP region.
+func PreferredRegionName(r *NetcheckReport) string {
+	if len(r.RegionLatency) == 0 {
+		return "unknown (no response to latency probes)"
+	}
+	if region, ok := r.DERPMap.Regions[r.PreferredDERP]; ok {
+		return region.RegionName
+	}
+	return "none"
+}
+
+// NATMapping describes how the NAT maps this device's endpoints.
+func NATMapping(r *netcheck.Report) string {
+	varies, ok := r.MappingVariesByDestIP.Get()
+	switch {
+	case !ok:
+		return "unknown"
+	case varies:
+		return "varies by destination (hard NAT)"
+	default:
+		return "endpoint independent (easy NAT)"
+	}
+}
+
+// PortMapping lists the port mapping protocols found on the LAN.
+func PortMapping(r *netcheck.Report) string {
+	if !r.AnyPortMappingChecked() {
+		return "not checked"
+	}
+	var got []string
+	if r.UPnP.EqualBool(true) {
+		got = append(got, "UPnP")
+	}
+	if r.PMP.EqualBool(true) {
+		got = append(got, "NAT-PMP")
+	}
+	if r.PCP.EqualBool(true) {
+		got = append(got, "PCP")
+	}
+	if len(got) == 0 {
+		return "none"
+	}
+	return strings.Join(got, ", ")
+}
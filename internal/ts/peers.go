@@ -0,0 +1,103 @@
+package ts
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+// SortedPeers returns this device followed by its peers, devices of the same
+// user first, then by host name.
+func SortedPeers(status *ipnstate.Status) []*ipnstate.PeerStatus {
+	peers := []*ipnstate.PeerStatus{}
+	if status == nil {
+		return peers
+	}
+	for _, v := range status.Peer {
+		peers = append(peers, v)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		ownNode1 := status.Self.UserID == peers[i].UserID
+		ownNode2 := status.Self.UserID == peers[j].UserID
+		first := fmt.Sprint(!ownNode1) + strings.ToLower(peers[i].HostName)
+		second := fmt.Sprint(!ownNode2) + strings.ToLower(peers[j].HostName)
+		return first < second
+	})
+	return append([]*ipnstate.PeerStatus{status.Self}, peers...)
+}
+
+// FindPeer looks up a device by host name, MagicDNS name, Tailscale IP or
+// node ID, like the `tailscale` CLI does.
+func FindPeer(status *ipnstate.Status, query string) (*ipnstate.PeerStatus, error) {
+	var found []*ipnstate.PeerStatus
+	for _, p := range SortedPeers(status) {
+		if peerMatches(p, query) {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no device named %q", query)
+	case 1:
+		return found[0], nil
+	}
+	var names []string
+	for _, p := range found {
+		names = append(names, strings.TrimSuffix(p.DNSName, "."))
+	}
+	return nil, fmt.Errorf("%q matches more than one device: %s", query, strings.Join(names, ", "))
+}
+
+func peerMatches(p *ipnstate.PeerStatus, query string) bool {
+	dnsName := strings.TrimSuffix(p.DNSName, ".")
+	shortName, _, _ := strings.Cut(dnsName, ".")
+	switch {
+	case strings.EqualFold(p.HostName, query),
+		strings.EqualFold(dnsName, strings.TrimSuffix(query, ".")),
+		strings.EqualFold(shortName, query),
+		string(p.ID) == query:
+		return true
+	}
+	for _, ip := range p.TailscaleIPs {
+		if ip.String() == query {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTagged reports whether p is owned by its ACL tags rather than a user.
+func IsTagged(p *ipnstate.PeerStatus) bool {
+	return p.Tags != nil && p.Tags.Len() > 0
+}
+
+// OwnerLogin returns the login name of the owner of p, or "tagged device"
+// when p is owned by its ACL tags.
+func OwnerLogin(status *ipnstate.Status, p *ipnstate.PeerStatus) string {
+	if IsTagged(p) {
+		return "tagged device"
+	}
+	return status.User[p.UserID].LoginName
+}
+
+// HasAnyTag reports whether p has one of tags.
+func HasAnyTag(p *ipnstate.PeerStatus, tags []string) bool {
+	return IsTagged(p) && slices.ContainsFunc(tags, func(tag string) bool {
+		return slices.Contains(p.Tags.AsSlice(), tag)
+	})
+}
+
+// TailnetTags returns the ACL tags of this device and its peers, sorted.
+func TailnetTags(status *ipnstate.Status) []string {
+	var tags []string
+	for _, p := range SortedPeers(status) {
+		if IsTagged(p) {
+			tags = append(tags, p.Tags.AsSlice()...)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
@@ -0,0 +1,126 @@
+package ts
+
+import (
+	"fmt"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+)
+
+// PingSample is the outcome of a single ping.
+type PingSample struct {
+	Latency time.Duration
+	Direct  bool // answered over a direct endpoint rather than DERP
+	Lost    bool
+	At      time.Time
+}
+
+// PingStats summarizes a series of pings like `ping` does at exit.
+type PingStats struct {
+	Type    tailcfg.PingType
+	Started time.Time
+	Samples []PingSample
+}
+
+func NewPingStats(pingType tailcfg.PingType) *PingStats {
+	return &PingStats{Type: pingType, Started: time.Now()}
+}
+
+// Add records the result of a ping, errors and timeouts count as lost.
+func (s *PingStats) Add(pr *ipnstate.PingResult, err error) {
+	sample := PingSample{At: time.Now()}
+	if err != nil || pr == nil || (pr.Err != "" && !pr.IsLocalIP) {
+		sample.Lost = true
+	} else {
+		sample.Latency = time.Duration(pr.LatencySeconds * float64(time.Second))
+		sample.Direct = pr.Endpoint != ""
+	}
+	s.Samples = append(s.Samples, sample)
+}
+
+func (s *PingStats) Sent() int {
+	return len(s.Samples)
+}
+
+func (s *PingStats) Received() int {
+	n := 0
+	for _, sample := range s.Samples {
+		if !sample.Lost {
+			n++
+		}
+	}
+	return n
+}
+
+// Loss returns the percentage of lost pings.
+func (s *PingStats) Loss() float64 {
+	if s.Sent() == 0 {
+		return 0
+	}
+	return float64(s.Sent()-s.Received()) / float64(s.Sent()) * 100
+}
+
+// Latencies returns the latency of every answered ping, in order.
+func (s *PingStats) Latencies() []time.Duration {
+	var latencies []time.Duration
+	for _, sample := range s.Samples {
+		if !sample.Lost {
+			latencies = append(latencies, sample.Latency)
+		}
+	}
+	return latencies
+}
+
+// RTT returns the min, avg and max latency and the jitter, the mean
+// difference between consecutive latencies.
+func (s *PingStats) RTT() (minRTT, avgRTT, maxRTT, jitter time.Duration) {
+	latencies := s.Latencies()
+	if len(latencies) == 0 {
+		return 0, 0, 0, 0
+	}
+	minRTT, maxRTT = latencies[0], latencies[0]
+	var sum, diffs time.Duration
+	for i, l := range latencies {
+		minRTT = min(minRTT, l)
+		maxRTT = max(maxRTT, l)
+		sum += l
+		if i > 0 {
+			diffs += (l - latencies[i-1]).Abs()
+		}
+	}
+	avgRTT = sum / time.Duration(len(latencies))
+	if len(latencies) > 1 {
+		jitter = diffs / time.Duration(len(latencies)-1)
+	}
+	return minRTT, avgRTT, maxRTT, jitter
+}
+
+// DirectAfter returns the index of the first direct pong that followed a
+// pong relayed over DERP, and how long after the first ping it arrived.
+func (s *PingStats) DirectAfter() (int, time.Duration, bool) {
+	relayed := false
+	for i, sample := range s.Samples {
+		switch {
+		case sample.Lost:
+		case !sample.Direct:
+			relayed = true
+		case relayed:
+			return i, sample.At.Sub(s.Started), true
+		default:
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+// Summary formats the statistics like the last lines of `ping`.
+func (s *PingStats) Summary() []string {
+	lines := []string{fmt.Sprintf("%d sent, %d received, %.0f%% loss", s.Sent(), s.Received(), s.Loss())}
+	if s.Received() > 0 {
+		minRTT, avgRTT, maxRTT, jitter := s.RTT()
+		ms := func(d time.Duration) string { return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond)) }
+		lines = append(lines, fmt.Sprintf("rtt min/avg/max/jitter = %s/%s/%s/%s ms", ms(minRTT), ms(avgRTT), ms(maxRTT), ms(jitter)))
+	}
+	return lines
+}
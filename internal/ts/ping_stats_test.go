@@ -0,0 +1,85 @@
+package ts
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+)
+
+type pingReply struct {
+	pr  *ipnstate.PingResult
+	err error
+}
+
+func direct(ms float64) pingReply {
+	return pingReply{pr: &ipnstate.PingResult{LatencySeconds: ms / 1000, Endpoint: "192.0.2.1:41641"}}
+}
+
+func relayed(ms float64) pingReply {
+	return pingReply{pr: &ipnstate.PingResult{LatencySeconds: ms / 1000, DERPRegionCode: "fra"}}
+}
+
+func TestPingStats(t *testing.T) {
+	lost := pingReply{err: errors.New("timeout")}
+	tests := []struct {
+		name    string
+		replies []pingReply
+		summary []string
+		direct  int // index of the first direct pong after a relayed one, -1 for none
+	}{
+		{
+			name:    "none",
+			summary: []string{"0 sent, 0 received, 0% loss"},
+			direct:  -1,
+		},
+		{
+			name:    "direct",
+			replies: []pingReply{direct(10), direct(20), direct(30)},
+			summary: []string{"3 sent, 3 received, 0% loss", "rtt min/avg/max/jitter = 10.0/20.0/30.0/10.0 ms"},
+			direct:  -1,
+		},
+		{
+			name:    "lost",
+			replies: []pingReply{direct(10), lost, direct(30), {pr: &ipnstate.PingResult{Err: "no reply"}}},
+			summary: []string{"4 sent, 2 received, 50% loss", "rtt min/avg/max/jitter = 10.0/20.0/30.0/20.0 ms"},
+			direct:  -1,
+		},
+		{
+			name:    "all lost",
+			replies: []pingReply{lost, {}},
+			summary: []string{"2 sent, 0 received, 100% loss"},
+			direct:  -1,
+		},
+		{
+			name:    "local IP",
+			replies: []pingReply{{pr: &ipnstate.PingResult{Err: "is local Tailscale IP", IsLocalIP: true}}},
+			summary: []string{"1 sent, 1 received, 0% loss", "rtt min/avg/max/jitter = 0.0/0.0/0.0/0.0 ms"},
+			direct:  -1,
+		},
+		{
+			name:    "relayed then direct",
+			replies: []pingReply{relayed(40), lost, relayed(60), direct(5)},
+			summary: []string{"4 sent, 3 received, 25% loss", "rtt min/avg/max/jitter = 5.0/35.0/60.0/37.5 ms"},
+			direct:  3,
+		},
+	}
+	for _, tt := range tests {
+		s := NewPingStats(tailcfg.PingDisco)
+		for _, r := range tt.replies {
+			s.Add(r.pr, r.err)
+		}
+		if got := s.Summary(); !slices.Equal(got, tt.summary) {
+			t.Errorf("%s: Summary() = %q, want %q", tt.name, got, tt.summary)
+		}
+		i, _, ok := s.DirectAfter()
+		if !ok {
+			i = -1
+		}
+		if i != tt.direct {
+			t.Errorf("%s: DirectAfter() = %d, want %d", tt.name, i, tt.direct)
+		}
+	}
+}
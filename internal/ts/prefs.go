@@ -0,0 +1,139 @@
+package ts
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"tailscale.com/ipn"
+	"tailscale.com/tailcfg"
+	"tailscale.com/util/dnsname"
+)
+
+// EditablePrefs holds the user-facing preferences shown in the prefs editor.
+type EditablePrefs struct {
+	Hostname      string
+	ShieldsUp     bool
+	AcceptDNS     bool
+	RunSSH        bool
+	AdvertiseTags []string
+	AutoUpdate    bool
+	OperatorUser  string
+}
+
+// PrefsChange is a single changed field between two EditablePrefs.
+type PrefsChange struct {
+	Field    string
+	Old, New string
+}
+
+func NewEditablePrefs(p *ipn.Prefs) EditablePrefs {
+	if p == nil {
+		return EditablePrefs{}
+	}
+	return EditablePrefs{
+		Hostname:      p.Hostname,
+		ShieldsUp:     p.ShieldsUp,
+		AcceptDNS:     p.CorpDNS,
+		RunSSH:        p.RunSSH,
+		AdvertiseTags: slices.Clone(p.AdvertiseTags),
+		AutoUpdate:    p.AutoUpdate.Apply.EqualBool(true),
+		OperatorUser:  p.OperatorUser,
+	}
+}
+
+// ParseTags parses a comma or space separated list of ACL tags.
+func ParseTags(s string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if err := tailcfg.CheckTag(tag); err != nil {
+			return nil, fmt.Errorf("tag %q: %w", tag, err)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (p EditablePrefs) Validate() error {
+	if p.Hostname != "" {
+		if err := dnsname.ValidHostname(p.Hostname); err != nil {
+			return fmt.Errorf("hostname: %w", err)
+		}
+	}
+	for _, tag := range p.AdvertiseTags {
+		if err := tailcfg.CheckTag(tag); err != nil {
+			return fmt.Errorf("tag %q: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// Diff returns the fields that differ from p in next.
+func (p EditablePrefs) Diff(next EditablePrefs) []PrefsChange {
+	var changes []PrefsChange
+	add := func(field string, old, new any) {
+		if fmt.Sprint(old) != fmt.Sprint(new) {
+			changes = append(changes, PrefsChange{Field: field, Old: fmt.Sprint(old), New: fmt.Sprint(new)})
+		}
+	}
+	add("Hostname", p.Hostname, next.Hostname)
+	add("Shields up", p.ShieldsUp, next.ShieldsUp)
+	add("Accept DNS", p.AcceptDNS, next.AcceptDNS)
+	add("Run SSH server", p.RunSSH, next.RunSSH)
+	add("Advertise tags", strings.Join(p.AdvertiseTags, ","), strings.Join(next.AdvertiseTags, ","))
+	add("Auto-update", p.AutoUpdate, next.AutoUpdate)
+	add("Operator user", p.OperatorUser, next.OperatorUser)
+	return changes
+}
+
+// MaskedPrefs builds an ipn.MaskedPrefs setting only the fields that
+// differ from p in next.
+func (p EditablePrefs) MaskedPrefs(next EditablePrefs) *ipn.MaskedPrefs {
+	mp := &ipn.MaskedPrefs{}
+	if p.Hostname != next.Hostname {
+		mp.Hostname = next.Hostname
+		mp.HostnameSet = true
+	}
+	if p.ShieldsUp != next.ShieldsUp {
+		mp.ShieldsUp = next.ShieldsUp
+		mp.ShieldsUpSet = true
+	}
+	if p.AcceptDNS != next.AcceptDNS {
+		mp.CorpDNS = next.AcceptDNS
+		mp.CorpDNSSet = true
+	}
+	if p.RunSSH != next.RunSSH {
+		mp.RunSSH = next.RunSSH
+		mp.RunSSHSet = true
+	}
+	if !slices.Equal(p.AdvertiseTags, next.AdvertiseTags) {
+		mp.AdvertiseTags = next.AdvertiseTags
+		mp.AdvertiseTagsSet = true
+	}
+	if p.AutoUpdate != next.AutoUpdate {
+		mp.AutoUpdate.Apply.Set(next.AutoUpdate)
+		mp.AutoUpdateSet.ApplySet = true
+		if next.AutoUpdate {
+			// Applying updates requires checking for them.
+			mp.AutoUpdate.Check = true
+			mp.AutoUpdateSet.CheckSet = true
+		}
+	}
+	if p.OperatorUser != next.OperatorUser {
+		mp.OperatorUser = next.OperatorUser
+		mp.OperatorUserSet = true
+	}
+	return mp
+}
+
+func GetPrefs(b Backend) (*ipn.Prefs, error) {
+	return b.GetPrefs(context.Background())
+}
+
+// Equivalent to `tailscale set` with the fields set in mp.
+func EditPrefs(b Backend, mp *ipn.MaskedPrefs) (*ipn.Prefs, error) {
+	return b.EditPrefs(context.Background(), mp)
+}
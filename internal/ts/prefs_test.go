@@ -0,0 +1,83 @@
+package ts
+
+import (
+	"reflect"
+	"testing"
+
+	"tailscale.com/ipn"
+)
+
+func TestEditPrefs(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(p *EditablePrefs)
+		want []string // fields set in the MaskedPrefs sent
+	}{
+		{
+			name: "no change",
+			edit: func(p *EditablePrefs) {},
+		},
+		{
+			name: "hostname",
+			edit: func(p *EditablePrefs) { p.Hostname = "workstation" },
+			want: []string{"Hostname"},
+		},
+		{
+			name: "toggles",
+			edit: func(p *EditablePrefs) { p.ShieldsUp, p.AcceptDNS, p.RunSSH = true, !p.AcceptDNS, true },
+			want: []string{"CorpDNS", "RunSSH", "ShieldsUp"},
+		},
+		{
+			name: "tags and operator",
+			edit: func(p *EditablePrefs) { p.AdvertiseTags, p.OperatorUser = []string{"tag:dev"}, "me" },
+			want: []string{"AdvertiseTags", "OperatorUser"},
+		},
+		{
+			name: "auto-update",
+			edit: func(p *EditablePrefs) { p.AutoUpdate = true },
+			want: []string{"AutoUpdate"},
+		},
+	}
+	for _, tt := range tests {
+		f := NewFakeBackend(FakeStatus())
+		prefs, err := GetPrefs(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old := NewEditablePrefs(prefs)
+		next := NewEditablePrefs(prefs)
+		tt.edit(&next)
+		prefs, err = EditPrefs(f, old.MaskedPrefs(next))
+		if err != nil {
+			t.Errorf("%s: EditPrefs() error = %v", tt.name, err)
+			continue
+		}
+		if got := NewEditablePrefs(prefs); !reflect.DeepEqual(got, next) {
+			t.Errorf("%s: prefs = %+v, want %+v", tt.name, got, next)
+		}
+		edits := f.Edits()
+		if len(edits) != 1 {
+			t.Errorf("%s: %d edits, want 1", tt.name, len(edits))
+			continue
+		}
+		if got := setFields(edits[0]); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: set %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// setFields returns the prefs marked as set in mp, without the Set suffix.
+func setFields(mp *ipn.MaskedPrefs) []string {
+	var fields []string
+	v := reflect.ValueOf(*mp)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		switch f := v.Field(i); {
+		case f.Kind() == reflect.Bool && f.Bool():
+			fields = append(fields, name[:len(name)-len("Set")])
+		case f.Kind() == reflect.Struct && name != "Prefs" && !f.IsZero():
+			fields = append(fields, name[:len(name)-len("Set")])
+		}
+	}
+	return fields
+}
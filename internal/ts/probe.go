@@ -0,0 +1,104 @@
+package ts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
+)
+
+// ProbeOptions bound the disco pings sent to every online peer in the
+// background.
+type ProbeOptions struct {
+	Enabled     bool          // start probing right away
+	Concurrency int           // max number of pings in flight
+	Rate        time.Duration // min wait between sending two pings
+	Interval    time.Duration // wait between two rounds
+	Timeout     time.Duration // timeout of a single ping
+}
+
+func DefaultProbeOptions() ProbeOptions {
+	return ProbeOptions{
+		Concurrency: 4,
+		Rate:        100 * time.Millisecond,
+		Interval:    30 * time.Second,
+		Timeout:     5 * time.Second,
+	}
+}
+
+// PeerLatency is the outcome of the last probe of a peer.
+type PeerLatency struct {
+	Latency time.Duration
+	Direct  bool
+	DERP    string // region code of the relay, when not direct
+	Lost    bool
+	At      time.Time
+}
+
+func (l PeerLatency) String() string {
+	switch {
+	case l.Lost:
+		return "no reply"
+	case l.Direct:
+		return fmt.Sprintf("%v direct", l.Latency.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%v via DERP(%s)", l.Latency.Round(time.Millisecond), l.DERP)
+}
+
+// ProbePeers pings every online peer once and returns their latencies. Peers
+// not probed before ctx is done are left out.
+func ProbePeers(ctx context.Context, b Backend, status *ipnstate.Status, opts ProbeOptions) map[key.NodePublic]PeerLatency {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies = map[key.NodePublic]PeerLatency{}
+		sem       = make(chan struct{}, max(opts.Concurrency, 1))
+	)
+	if status == nil {
+		return latencies
+	}
+	var last time.Time
+	for _, peer := range status.Peer {
+		if !peer.Online || len(peer.TailscaleIPs) == 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Until(last.Add(opts.Rate))):
+		}
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		last = time.Now()
+		wg.Add(1)
+		go func(peer *ipnstate.PeerStatus) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			pr, err := Ping(ctx, b, peer.TailscaleIPs[0], tailcfg.PingDisco, opts.Timeout)
+			if ctx.Err() != nil {
+				return
+			}
+			l := PeerLatency{At: time.Now()}
+			if err != nil || pr == nil || pr.Err != "" {
+				l.Lost = true
+			} else {
+				l.Latency = time.Duration(pr.LatencySeconds * float64(time.Second))
+				l.Direct = pr.Endpoint != ""
+				l.DERP = pr.DERPRegionCode
+			}
+			mu.Lock()
+			latencies[peer.PublicKey] = l
+			mu.Unlock()
+		}(peer)
+	}
+	wg.Wait()
+	return latencies
+}
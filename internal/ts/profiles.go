@@ -0,0 +1,35 @@
+package ts
+
+import (
+	"context"
+
+	"tailscale.com/ipn"
+)
+
+// Equivalent to `tailscale switch --list`
+func GetProfiles(b Backend) (current ipn.LoginProfile, all []ipn.LoginProfile, err error) {
+	return b.ProfileStatus(context.Background())
+}
+
+// Equivalent to `tailscale switch <id>`
+func SwitchProfile(b Backend, id ipn.ProfileID) error {
+	return b.SwitchProfile(context.Background(), id)
+}
+
+func DeleteProfile(b Backend, id ipn.ProfileID) error {
+	return b.DeleteProfile(context.Background(), id)
+}
+
+// NewProfile switches to a new, logged out profile.
+// Equivalent to `tailscale login` while already logged in.
+func NewProfile(b Backend) error {
+	return b.SwitchToEmptyProfile(context.Background())
+}
+
+// ProfileTailnet returns the tailnet name of p.
+func ProfileTailnet(p ipn.LoginProfile) string {
+	if p.NetworkProfile.DomainName != "" {
+		return p.NetworkProfile.DomainName
+	}
+	return p.NetworkProfile.MagicDNSName
+}
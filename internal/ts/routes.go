@@ -0,0 +1,97 @@
+package ts
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"tailscale.com/ipn"
+	"tailscale.com/net/tsaddr"
+	"tailscale.com/types/views"
+)
+
+// ParseRoute parses and validates a subnet route given in CIDR notation.
+func ParseRoute(s string) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not a valid CIDR prefix", s)
+	}
+	if p != p.Masked() {
+		return netip.Prefix{}, fmt.Errorf("%s has non-address bits set; expected %s", p, p.Masked())
+	}
+	if p.Bits() == 0 {
+		return netip.Prefix{}, fmt.Errorf("%s is an exit route; use \"Offer Exit Node\" instead", p)
+	}
+	if p.Overlaps(tsaddr.CGNATRange()) || p.Overlaps(tsaddr.TailscaleULARange()) {
+		return netip.Prefix{}, fmt.Errorf("%s overlaps the Tailscale address range", p)
+	}
+	return p, nil
+}
+
+// SubnetRoutes returns the advertised routes without the exit node routes.
+func SubnetRoutes(prefs *ipn.Prefs) []netip.Prefix {
+	if prefs == nil {
+		return nil
+	}
+	return tsaddr.FilterPrefixesCopy(views.SliceOf(prefs.AdvertiseRoutes), func(p netip.Prefix) bool {
+		return p.Bits() != 0
+	})
+}
+
+// AdvertiseRoute adds route to the advertised routes.
+// Equivalent to `tailscale set --advertise-routes=<current>,<route>`
+func AdvertiseRoute(b Backend, route netip.Prefix) (*ipn.Prefs, error) {
+	return editRoutes(b, func(routes []netip.Prefix) ([]netip.Prefix, error) {
+		if slices.Contains(routes, route) {
+			return nil, fmt.Errorf("%s is already advertised", route)
+		}
+		return append(routes, route), nil
+	})
+}
+
+// UnadvertiseRoute removes route from the advertised routes.
+func UnadvertiseRoute(b Backend, route netip.Prefix) (*ipn.Prefs, error) {
+	return editRoutes(b, func(routes []netip.Prefix) ([]netip.Prefix, error) {
+		i := slices.Index(routes, route)
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not advertised", route)
+		}
+		return slices.Delete(routes, i, i+1), nil
+	})
+}
+
+func editRoutes(b Backend, edit func([]netip.Prefix) ([]netip.Prefix, error)) (*ipn.Prefs, error) {
+	prefs, err := b.GetPrefs(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	routes, err := edit(slices.Clone(prefs.AdvertiseRoutes))
+	if err != nil {
+		return nil, err
+	}
+	tsaddr.SortPrefixes(routes)
+	return b.EditPrefs(context.Background(), &ipn.MaskedPrefs{
+		Prefs: ipn.Prefs{
+			AdvertiseRoutes: routes,
+		},
+		AdvertiseRoutesSet: true,
+	})
+}
+
+// Equivalent to `tailscale set --accept-routes=<accept>`
+func SetAcceptRoutes(b Backend, accept bool) (*ipn.Prefs, error) {
+	return b.EditPrefs(context.Background(), &ipn.MaskedPrefs{
+		Prefs: ipn.Prefs{
+			RouteAll: accept,
+		},
+		RouteAllSet: true,
+	})
+}
+
+// RouteApproved reports whether route is one of the primary routes of the
+// given node, meaning it has been approved and is active.
+func RouteApproved(primary *views.Slice[netip.Prefix], route netip.Prefix) bool {
+	return primary != nil && views.SliceContains(*primary, route)
+}
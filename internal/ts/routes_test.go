@@ -0,0 +1,37 @@
+package ts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRoute(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  string
+	}{
+		{in: "10.0.0.0/24", want: "10.0.0.0/24"},
+		{in: " 192.168.1.0/24 ", want: "192.168.1.0/24"},
+		{in: "fd00::/64", want: "fd00::/64"},
+		{in: "10.0.0.1/24", err: "non-address bits set; expected 10.0.0.0/24"},
+		{in: "0.0.0.0/0", err: "is an exit route"},
+		{in: "::/0", err: "is an exit route"},
+		{in: "100.64.0.0/16", err: "overlaps the Tailscale address range"},
+		{in: "fd7a:115c:a1e0::/64", err: "overlaps the Tailscale address range"},
+		{in: "10.0.0.0", err: "is not a valid CIDR prefix"},
+		{in: "", err: "is not a valid CIDR prefix"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRoute(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseRoute(%q) error = %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseRoute(%q) = %v, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package ts
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/types/key"
+)
+
+// PeerSort is a field the node list can be sorted by.
+type PeerSort int
+
+const (
+	SortDefault PeerSort = iota
+	SortHostname
+	SortOnline
+	SortOS
+	SortOwner
+	SortLastSeen
+	SortKeyExpiry
+	SortTraffic
+	SortExitNode
+	SortLatency
+	SortTopTalkers
+)
+
+func (s PeerSort) String() string {
+	return [...]string{
+		"default",
+		"hostname",
+		"online",
+		"OS",
+		"owner",
+		"last seen",
+		"key expiry",
+		"traffic",
+		"exit node",
+		"latency",
+		"top talkers",
+	}[s]
+}
+
+// Name is the name of s in the config file.
+func (s PeerSort) Name() string {
+	return strings.ReplaceAll(strings.ToLower(s.String()), " ", "_")
+}
+
+func ParsePeerSort(name string) (PeerSort, error) {
+	var names []string
+	for s := SortDefault; s <= SortTopTalkers; s++ {
+		if s.Name() == name {
+			return s, nil
+		}
+		names = append(names, s.Name())
+	}
+	return 0, fmt.Errorf("unknown sort %q, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// PeerOrder is the order of the node list.
+type PeerOrder struct {
+	By         PeerSort
+	Descending bool
+}
+
+func (o PeerOrder) String() string {
+	s := "by " + o.By.String()
+	if o.Descending {
+		s += ", descending"
+	}
+	return s
+}
+
+func cmpBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	}
+	return 1
+}
+
+func exitNodeRank(p *ipnstate.PeerStatus) int {
+	switch {
+	case p.ExitNode:
+		return 0
+	case p.ExitNodeOption:
+		return 1
+	}
+	return 2
+}
+
+// lastSeen returns now for online peers, so they tie.
+func lastSeen(p *ipnstate.PeerStatus, now time.Time) time.Time {
+	if p.Online {
+		return now
+	}
+	return p.LastSeen
+}
+
+// SortPeers sorts the peers of SortedPeers, this device stays first. Strings
+// sort from a to z, numbers and times from low to high, online devices and
+// exit nodes and the peers with the highest traffic rates come first. Peers
+// without a key expiry or a latency go last in both directions, ties keep
+// the default order.
+func SortPeers(status *ipnstate.Status, peers []*ipnstate.PeerStatus, order PeerOrder, latencies map[key.NodePublic]PeerLatency, traffic TrafficDataMsg) {
+	if order == (PeerOrder{}) || len(peers) < 2 {
+		return
+	}
+	rank := map[*ipnstate.PeerStatus]int{}
+	for i, p := range peers {
+		rank[p] = i
+	}
+	missing := func(*ipnstate.PeerStatus) bool { return false }
+	var compare func(a, b *ipnstate.PeerStatus) int
+	switch order.By {
+	case SortDefault:
+		compare = func(a, b *ipnstate.PeerStatus) int { return cmp.Compare(rank[a], rank[b]) }
+	case SortHostname:
+		compare = func(a, b *ipnstate.PeerStatus) int {
+			return cmp.Compare(strings.ToLower(a.HostName), strings.ToLower(b.HostName))
+		}
+	case SortOnline:
+		compare = func(a, b *ipnstate.PeerStatus) int { return cmpBool(a.Online, b.Online) }
+	case SortOS:
+		compare = func(a, b *ipnstate.PeerStatus) int { return cmp.Compare(strings.ToLower(a.OS), strings.ToLower(b.OS)) }
+	case SortOwner:
+		compare = func(a, b *ipnstate.PeerStatus) int {
+			return cmp.Compare(status.User[a.UserID].LoginName, status.User[b.UserID].LoginName)
+		}
+	case SortLastSeen:
+		now := time.Now()
+		compare = func(a, b *ipnstate.PeerStatus) int { return lastSeen(a, now).Compare(lastSeen(b, now)) }
+	case SortKeyExpiry:
+		missing = func(p *ipnstate.PeerStatus) bool { return p.KeyExpiry == nil }
+		compare = func(a, b *ipnstate.PeerStatus) int { return a.KeyExpiry.Compare(*b.KeyExpiry) }
+	case SortTraffic:
+		compare = func(a, b *ipnstate.PeerStatus) int { return cmp.Compare(a.RxBytes+a.TxBytes, b.RxBytes+b.TxBytes) }
+	case SortExitNode:
+		compare = func(a, b *ipnstate.PeerStatus) int { return cmp.Compare(exitNodeRank(a), exitNodeRank(b)) }
+	case SortLatency:
+		missing = func(p *ipnstate.PeerStatus) bool {
+			l, ok := latencies[p.PublicKey]
+			return !ok || l.Lost
+		}
+		compare = func(a, b *ipnstate.PeerStatus) int {
+			return cmp.Compare(latencies[a.PublicKey].Latency, latencies[b.PublicKey].Latency)
+		}
+	case SortTopTalkers:
+		compare = func(a, b *ipnstate.PeerStatus) int {
+			return cmp.Compare(traffic[b.PublicKey].Rate(), traffic[a.PublicKey].Rate())
+		}
+	}
+	slices.SortFunc(peers[1:], func(a, b *ipnstate.PeerStatus) int {
+		if c := cmpBool(!missing(a), !missing(b)); c != 0 {
+			return c
+		}
+		c := 0
+		if !missing(a) {
+			c = compare(a, b)
+		}
+		if order.Descending {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(rank[a], rank[b])
+		}
+		return c
+	})
+}
@@ -0,0 +1,60 @@
+package ts
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"tailscale.com/types/key"
+)
+
+func TestSortPeers(t *testing.T) {
+	status := FakeStatus()
+	latencies := map[key.NodePublic]PeerLatency{
+		peerByName(status, "server").PublicKey: {Latency: 20 * time.Millisecond},
+		peerByName(status, "ci").PublicKey:     {Latency: 5 * time.Millisecond},
+		peerByName(status, "exit").PublicKey:   {Lost: true},
+	}
+	traffic := TrafficDataMsg{
+		peerByName(status, "server").PublicKey: {RxRate: 60, TxRate: 40},
+		peerByName(status, "phone").PublicKey:  {RxRate: 10},
+	}
+	tests := []struct {
+		order PeerOrder
+		want  []string
+	}{
+		{PeerOrder{}, []string{"laptop", "exit", "server", "ci", "phone"}},
+		{PeerOrder{By: SortDefault, Descending: true}, []string{"laptop", "phone", "ci", "server", "exit"}},
+		{PeerOrder{By: SortHostname}, []string{"laptop", "ci", "exit", "phone", "server"}},
+		{PeerOrder{By: SortHostname, Descending: true}, []string{"laptop", "server", "phone", "exit", "ci"}},
+		{PeerOrder{By: SortOnline}, []string{"laptop", "exit", "server", "ci", "phone"}},
+		{PeerOrder{By: SortOS}, []string{"laptop", "phone", "exit", "server", "ci"}},
+		{PeerOrder{By: SortOwner}, []string{"laptop", "ci", "phone", "exit", "server"}},
+		{PeerOrder{By: SortLastSeen}, []string{"laptop", "phone", "exit", "server", "ci"}},
+		{PeerOrder{By: SortKeyExpiry}, []string{"laptop", "ci", "exit", "server", "phone"}},
+		{PeerOrder{By: SortKeyExpiry, Descending: true}, []string{"laptop", "ci", "exit", "server", "phone"}},
+		{PeerOrder{By: SortTraffic}, []string{"laptop", "ci", "phone", "exit", "server"}},
+		{PeerOrder{By: SortExitNode}, []string{"laptop", "exit", "server", "ci", "phone"}},
+		{PeerOrder{By: SortLatency}, []string{"laptop", "ci", "server", "exit", "phone"}},
+		{PeerOrder{By: SortLatency, Descending: true}, []string{"laptop", "server", "ci", "exit", "phone"}},
+		{PeerOrder{By: SortTopTalkers}, []string{"laptop", "server", "phone", "exit", "ci"}},
+	}
+	for _, tt := range tests {
+		peers := SortedPeers(status)
+		SortPeers(status, peers, tt.order, latencies, traffic)
+		if got := hostNames(peers); !slices.Equal(got, tt.want) {
+			t.Errorf("SortPeers(%s) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestParsePeerSort(t *testing.T) {
+	for s := SortDefault; s <= SortTopTalkers; s++ {
+		if got, err := ParsePeerSort(s.Name()); err != nil || got != s {
+			t.Errorf("ParsePeerSort(%q) = %v, %v, want %v", s.Name(), got, err, s)
+		}
+	}
+	if _, err := ParsePeerSort("size"); err == nil {
+		t.Error("ParsePeerSort(\"size\") succeeded, want an error")
+	}
+}
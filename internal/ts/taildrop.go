@@ -0,0 +1,212 @@
+package ts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"tailscale.com/client/tailscale/apitype"
+	"tailscale.com/tailcfg"
+)
+
+// IsFileTarget reports whether files can be sent to the given peer with
+// Taildrop.
+func IsFileTarget(b Backend, id tailcfg.StableNodeID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	targets, err := b.FileTargets(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range targets {
+		if t.Node != nil && t.Node.StableID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Transfer is a single file being sent to a peer with Taildrop. Progress
+// can be read from other goroutines while Send is running.
+type Transfer struct {
+	Name    string
+	Path    string
+	Size    int64
+	Started time.Time
+	sent    atomic.Int64
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewTransfer prepares the regular file at path for sending.
+func NewTransfer(path string) (*Transfer, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", filepath.Base(path))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Transfer{
+		Name:    filepath.Base(path),
+		Path:    path,
+		Size:    fi.Size(),
+		Started: time.Now(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// Sent returns the number of bytes handed to tailscaled so far.
+func (t *Transfer) Sent() int64 {
+	return t.sent.Load()
+}
+
+// Progress returns the completed fraction, between 0 and 1.
+func (t *Transfer) Progress() float64 {
+	if t.Size <= 0 {
+		return 1
+	}
+	return min(float64(t.Sent())/float64(t.Size), 1)
+}
+
+// Throughput returns the average speed in bytes per second.
+func (t *Transfer) Throughput() float64 {
+	elapsed := time.Since(t.Started).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(t.Sent()) / elapsed
+}
+
+// Cancel aborts a running Send.
+func (t *Transfer) Cancel() {
+	t.cancel()
+}
+
+// Equivalent to `tailscale file cp <file> <target>:`
+// Returns context.Canceled if the transfer was cancelled.
+func (t *Transfer) Send(b Backend, target tailcfg.StableNodeID) error {
+	defer t.cancel()
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = b.PushFile(t.ctx, target, t.Size, t.Name, &countingReader{r: f, n: &t.sent})
+	if err != nil && errors.Is(t.ctx.Err(), context.Canceled) {
+		return context.Canceled
+	}
+	return err
+}
+
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// FormatBytes formats n using binary units, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// GetWaitingFiles returns the files received with Taildrop that have not
+// been saved yet.
+func GetWaitingFiles(b Backend) ([]apitype.WaitingFile, error) {
+	return b.WaitingFiles(context.Background())
+}
+
+// Equivalent to `tailscale file get <dir>` for a single file. A numbered
+// suffix is added to the name if dir already has a file with that name.
+// Returns the path the file was saved to.
+func SaveWaitingFile(b Backend, name, dir string) (string, error) {
+	ctx := context.Background()
+	rc, _, err := b.GetWaitingFile(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	f, err := createUnique(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), b.DeleteWaitingFile(ctx, name)
+}
+
+// DiscardWaitingFile deletes a received file without saving it.
+func DiscardWaitingFile(b Backend, name string) error {
+	return b.DeleteWaitingFile(context.Background(), name)
+}
+
+// DefaultSaveDir returns ~/Downloads if it exists, the home directory
+// otherwise.
+func DefaultSaveDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	downloads := filepath.Join(home, "Downloads")
+	if fi, err := os.Stat(downloads); err == nil && fi.IsDir() {
+		return downloads
+	}
+	return home
+}
+
+// ExpandHome replaces a leading ~ with the home directory.
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func createUnique(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; i < 1000; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return f, err
+	}
+	return nil, fmt.Errorf("too many files named %q in %s", name, dir)
+}
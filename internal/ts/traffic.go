@@ -0,0 +1,61 @@
+package ts
+
+import (
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/types/key"
+)
+
+// PeerTraffic is the traffic with a peer since tailscaled started, and the
+// rates in bytes per second since the previous sample.
+type PeerTraffic struct {
+	RxBytes int64
+	TxBytes int64
+	RxRate  int64
+	TxRate  int64
+}
+
+// Rate is the receive and transmit rate together.
+func (t PeerTraffic) Rate() int64 {
+	return t.RxRate + t.TxRate
+}
+
+// TrafficDataMsg is the traffic of the peers, by public key.
+type TrafficDataMsg map[key.NodePublic]PeerTraffic
+
+// minTrafficSample is the shortest time between two samples, status
+// refreshes closer to the previous one keep its rates.
+const minTrafficSample = time.Second
+
+// TrafficMeter computes the traffic rates of the peers from the counters of
+// consecutive statuses.
+type TrafficMeter struct {
+	last    TrafficDataMsg
+	sampled time.Time
+}
+
+// Sample returns the traffic of the peers in status taken at now. The rates
+// are unknown, so zero, on the first sample and after a counter reset.
+func (t *TrafficMeter) Sample(status *ipnstate.Status, now time.Time) TrafficDataMsg {
+	elapsed := now.Sub(t.sampled)
+	if t.last != nil && elapsed < minTrafficSample {
+		return t.last
+	}
+	traffic := TrafficDataMsg{}
+	for k, p := range status.Peer {
+		pt := PeerTraffic{RxBytes: p.RxBytes, TxBytes: p.TxBytes}
+		if prev, ok := t.last[k]; ok && p.RxBytes >= prev.RxBytes && p.TxBytes >= prev.TxBytes {
+			pt.RxRate = int64(float64(p.RxBytes-prev.RxBytes) / elapsed.Seconds())
+			pt.TxRate = int64(float64(p.TxBytes-prev.TxBytes) / elapsed.Seconds())
+		}
+		traffic[k] = pt
+	}
+	t.last, t.sampled = traffic, now
+	return traffic
+}
+
+// FormatRate formats a rate in bytes per second, e.g. "1.5 KiB/s".
+func FormatRate(rate int64) string {
+	return FormatBytes(rate) + "/s"
+}
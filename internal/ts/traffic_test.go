@@ -0,0 +1,33 @@
+package ts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrafficMeterSample(t *testing.T) {
+	status := FakeStatus()
+	server := peerByName(status, "server")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		at     time.Duration
+		rx, tx int64
+		want   PeerTraffic
+	}{
+		{"first sample", 0, 3000, 1000, PeerTraffic{RxBytes: 3000, TxBytes: 1000}},
+		{"too soon", 500 * time.Millisecond, 9000, 9000, PeerTraffic{RxBytes: 3000, TxBytes: 1000}},
+		{"rates", 2 * time.Second, 5000, 1500, PeerTraffic{RxBytes: 5000, TxBytes: 1500, RxRate: 1000, TxRate: 250}},
+		{"idle", 4 * time.Second, 5000, 1500, PeerTraffic{RxBytes: 5000, TxBytes: 1500}},
+		{"counter reset", 6 * time.Second, 100, 1600, PeerTraffic{RxBytes: 100, TxBytes: 1600}},
+		{"after reset", 7 * time.Second, 600, 1600, PeerTraffic{RxBytes: 600, TxBytes: 1600, RxRate: 500}},
+	}
+	var m TrafficMeter
+	for _, tt := range tests {
+		server.RxBytes, server.TxBytes = tt.rx, tt.tx
+		got := m.Sample(status, start.Add(tt.at))[server.PublicKey]
+		if got != tt.want {
+			t.Errorf("%s: Sample() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -7,37 +7,72 @@ import (
 	"net/netip"
 	"time"
 
-	"tailscale.com/client/tailscale"
 	"tailscale.com/ipn"
 	"tailscale.com/ipn/ipnstate"
 	"tailscale.com/tailcfg"
 )
 
-var lc tailscale.LocalClient
-
-func GetStatus() (*ipnstate.Status, error) {
-	return lc.Status(context.Background())
+func GetStatus(b Backend) (*ipnstate.Status, error) {
+	return b.Status(context.Background())
 }
 
 // Connect/Disconnect Tailscale network.
 // Equivalent to `tailscale up` (status=true) `tailscale down` (status=false) commands
-func SetTSStatus(status bool) {
-	lc.EditPrefs(context.Background(), &ipn.MaskedPrefs{
+func SetTSStatus(b Backend, status bool) error {
+	_, err := b.EditPrefs(context.Background(), &ipn.MaskedPrefs{
 		Prefs: ipn.Prefs{
 			WantRunning: status,
 		},
 		WantRunningSet: true,
 	})
+	return err
+}
+
+// PingOptions mirror the flags of `tailscale ping`.
+type PingOptions struct {
+	Count    int           // max number of pings, 0 for no limit
+	Interval time.Duration // wait between replies
+	Timeout  time.Duration // timeout of a single ping
+}
+
+func DefaultPingOptions() PingOptions {
+	return PingOptions{
+		Count:    10,
+		Interval: time.Second,
+		Timeout:  5 * time.Second,
+	}
 }
 
-func Ping(ip netip.Addr) (*ipnstate.PingResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	pr, err := lc.Ping(ctx, ip, tailcfg.PingDisco)
-	cancel()
-	return pr, err
+// PingTypes are the kinds of ping tailscaled can send. A disco pong only
+// proves the WireGuard path, TSMP and ICMP ones went through the peer's
+// engine and OS stack, a PeerAPI one reached the peer's HTTP server.
+var PingTypes = []tailcfg.PingType{
+	tailcfg.PingDisco,
+	tailcfg.PingTSMP,
+	tailcfg.PingICMP,
+	tailcfg.PingPeerAPI,
+}
+
+// Ping sends a single ping, giving up after timeout or once ctx is done.
+func Ping(ctx context.Context, b Backend, ip netip.Addr, pingType tailcfg.PingType, timeout time.Duration) (*ipnstate.PingResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return b.Ping(ctx, ip, pingType)
+}
+
+// PingVia returns the path a pong took, empty when tailscaled doesn't say,
+// as for TSMP and ICMP pings.
+func PingVia(pr *ipnstate.PingResult, pingType tailcfg.PingType) string {
+	switch {
+	case pingType == tailcfg.PingPeerAPI:
+		return pr.PeerAPIURL
+	case pr.DERPRegionID != 0:
+		return fmt.Sprintf("DERP(%s)", pr.DERPRegionCode)
+	}
+	return pr.Endpoint
 }
 
-func PingResultString(pr *ipnstate.PingResult) (string, error) {
+func PingResultString(pr *ipnstate.PingResult, pingType tailcfg.PingType) (string, error) {
 	if pr == nil {
 		return "", nil
 	}
@@ -50,12 +85,13 @@ func PingResultString(pr *ipnstate.PingResult) (string, error) {
 		return message, errors.New(pr.Err)
 	}
 	latency := time.Duration(pr.LatencySeconds * float64(time.Second)).Round(time.Millisecond)
-	via := pr.Endpoint
-	if pr.DERPRegionID != 0 {
-		via = fmt.Sprintf("DERP(%s)", pr.DERPRegionCode)
+	if pingType == tailcfg.PingPeerAPI {
+		message = fmt.Sprintf("hit peerapi of %s (%s) at %s in %v", pr.NodeIP, pr.NodeName, pr.PeerAPIURL, latency)
+		return message, nil
 	}
+	via := PingVia(pr, pingType)
 	if via == "" {
-		via = string(tailcfg.PingDisco)
+		via = string(pingType)
 	}
 	extra := ""
 	if pr.PeerAPIPort != 0 {
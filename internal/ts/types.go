@@ -2,8 +2,13 @@ package ts
 
 import (
 	"net/netip"
+	"time"
 
+	"tailscale.com/client/tailscale/apitype"
+	"tailscale.com/ipn"
 	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
 )
 
 type StatusDataMsg *ipnstate.Status
@@ -11,6 +16,44 @@ type StatusErrorMsg error
 type ConnectMsg bool
 type ToggleConnectionMsg bool
 type PingMsg netip.Addr
+type PrefsDataMsg *ipn.Prefs
+type PrefsErrorMsg error
+type SetExitNodeMsg tailcfg.StableNodeID
+type AllowLANAccessMsg bool
+type OfferExitNodeMsg bool
+type AdvertiseRouteMsg netip.Prefix
+type UnadvertiseRouteMsg netip.Prefix
+type AcceptRoutesMsg bool
+type EditPrefsMsg *ipn.MaskedPrefs
+type ProfilesDataMsg struct {
+	Current ipn.LoginProfile
+	All     []ipn.LoginProfile
+}
+type SwitchProfileMsg ipn.ProfileID
+type DeleteProfileMsg ipn.ProfileID
+type NewProfileMsg bool
+type ProfileErrorMsg error
+type LoginMsg bool
+type LogoutMsg bool
+type LoginErrorMsg error
+type AuthURLMsg string
+type WaitingFilesMsg []apitype.WaitingFile
+type SaveFileMsg struct {
+	Name string
+	Dir  string
+}
+type DiscardFileMsg string
+type TaildropErrorMsg error
+type RunNetcheckMsg bool
+type NetcheckDataMsg *NetcheckReport
+type NetcheckErrorMsg error
+type ToggleProbeMsg bool
+type ProbeDataMsg map[key.NodePublic]PeerLatency
+type BusNotifyMsg *ipn.Notify
+type BusErrorMsg struct {
+	Err     error
+	RetryIn time.Duration
+}
 
 type ActionType int
 
@@ -18,11 +61,30 @@ const (
 	ConnectAction ActionType = iota
 	OfferExitNode
 	PingAction
+	UseExitNodeAction
+	SendFileAction
+	ExitNodesAction
+	RoutesAction
+	PrefsAction
+	ProfilesAction
+	InboxAction
+	NetcheckAction
+	LogoutAction
 )
 
 func (f ActionType) String() string {
 	return [...]string{
 		"TSConnect",
+		"TSOfferExitNode",
 		"TSPing",
+		"TSUseExitNode",
+		"TSSendFile",
+		"TSExitNodes",
+		"TSRoutes",
+		"TSPrefs",
+		"TSProfiles",
+		"TSInbox",
+		"TSNetcheck",
+		"TSLogout",
 	}[f]
 }
@@ -3,6 +3,7 @@ package actionlist
 import (
 	"github.com/bilguun0203/tailscale-tui/internal/ts"
 	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -44,6 +45,14 @@ func (m *Model) SetSize(w int, h int) {
 	m.list.SetSize(w, h)
 }
 
+func (m *Model) SetItems(items []ActionListItem) tea.Cmd {
+	lis := []list.Item{}
+	for _, item := range items {
+		lis = append(lis, item)
+	}
+	return m.list.SetItems(lis)
+}
+
 func (m Model) SelectedItem() ActionListItem {
 	return m.list.SelectedItem().(ActionListItem)
 }
@@ -82,6 +91,7 @@ func New(items []ActionListItem, w int, h int) Model {
 	m := Model{
 		list: list.New(lis, d, w, h),
 	}
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewDetails)
 	m.list.Title = "Actions"
 	m.list.Styles.Title = constants.PrimaryTitleStyle
 	m.list.SetFilteringEnabled(false)
@@ -1,29 +1,234 @@
 package constants
 
-import "github.com/charmbracelet/lipgloss"
-
-var ColorBW = lipgloss.AdaptiveColor{Light: "#000", Dark: "#FFF"}
-var ColorNormal = lipgloss.AdaptiveColor{Light: "#1A1A1A", Dark: "#DDDDDD"}
-var ColorNormalInv = lipgloss.AdaptiveColor{Light: "#DDDDDD", Dark: "#1A1A1A"}
-var ColorDanger = lipgloss.AdaptiveColor{Light: "#ff005f", Dark: "#ff005f"}
-var ColorSuccess = lipgloss.AdaptiveColor{Light: "#00835a", Dark: "#00ffaf"}
-var ColorWarning = lipgloss.AdaptiveColor{Light: "#e9a000", Dark: "ffaf00"}
-var ColorDimmed = lipgloss.AdaptiveColor{Light: "#7c737c", Dark: "#777777"}
-var ColorMuted = lipgloss.AdaptiveColor{Light: "#6e5e6e", Dark: "#4D4D4D"}
-var ColorPrimary = lipgloss.AdaptiveColor{Light: "#008448", Dark: "#20F394"}
-var ColorSecondary = lipgloss.AdaptiveColor{Light: "#007c88", Dark: "#00E5FA"}
-
-var PrimaryTitleStyle = lipgloss.NewStyle().Padding(0, 1).Background(lipgloss.Color(ColorPrimary.Dark)).Foreground(lipgloss.Color("#000000"))
-var SecondaryTitleStyle = lipgloss.NewStyle().Padding(0, 1).Background(lipgloss.Color(ColorSecondary.Light)).Foreground(lipgloss.Color("#000000"))
-var WarningTitleStyle = lipgloss.NewStyle().Padding(0, 1).Background(lipgloss.Color(ColorWarning.Light)).Foreground(lipgloss.Color("#000000"))
-var NormalTextStyle = lipgloss.NewStyle().Foreground(ColorNormal)
-var DangerTextStyle = lipgloss.NewStyle().Foreground(ColorDanger)
-var SuccessTextStyle = lipgloss.NewStyle().Foreground(ColorSuccess)
-var WarningTextStyle = lipgloss.NewStyle().Foreground(ColorWarning)
-var DimmedTextStyle = lipgloss.NewStyle().Foreground(ColorDimmed)
-var MutedTextStyle = lipgloss.NewStyle().Foreground(ColorMuted)
-var PrimaryTextStyle = lipgloss.NewStyle().Foreground(ColorPrimary)
-var SecondaryTextStyle = lipgloss.NewStyle().Foreground(ColorSecondary)
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var ColorBW lipgloss.AdaptiveColor
+var ColorNormal lipgloss.AdaptiveColor
+var ColorNormalInv lipgloss.AdaptiveColor
+var ColorTitle lipgloss.AdaptiveColor
+var ColorDanger lipgloss.AdaptiveColor
+var ColorSuccess lipgloss.AdaptiveColor
+var ColorWarning lipgloss.AdaptiveColor
+var ColorDimmed lipgloss.AdaptiveColor
+var ColorMuted lipgloss.AdaptiveColor
+var ColorPrimary lipgloss.AdaptiveColor
+var ColorSecondary lipgloss.AdaptiveColor
+
+var PrimaryTitleStyle lipgloss.Style
+var SecondaryTitleStyle lipgloss.Style
+var WarningTitleStyle lipgloss.Style
+var NormalTextStyle lipgloss.Style
+var DangerTextStyle lipgloss.Style
+var SuccessTextStyle lipgloss.Style
+var WarningTextStyle lipgloss.Style
+var DimmedTextStyle lipgloss.Style
+var MutedTextStyle lipgloss.Style
+var PrimaryTextStyle lipgloss.Style
+var SecondaryTextStyle lipgloss.Style
 var LayoutStyle = lipgloss.NewStyle()
-var SpinnerStyle = lipgloss.NewStyle().Foreground(ColorPrimary)
+var SpinnerStyle lipgloss.Style
 var HeaderStyle = lipgloss.NewStyle().Margin(1, 2)
+
+// Theme holds the colors of the TUI, lipgloss picks the light or dark
+// variant from the terminal background. A theme without colors renders the
+// titles reversed instead.
+type Theme struct {
+	Normal    lipgloss.AdaptiveColor
+	Inverse   lipgloss.AdaptiveColor
+	Contrast  lipgloss.AdaptiveColor
+	Title     lipgloss.AdaptiveColor
+	Danger    lipgloss.AdaptiveColor
+	Success   lipgloss.AdaptiveColor
+	Warning   lipgloss.AdaptiveColor
+	Dimmed    lipgloss.AdaptiveColor
+	Muted     lipgloss.AdaptiveColor
+	Primary   lipgloss.AdaptiveColor
+	Secondary lipgloss.AdaptiveColor
+}
+
+// Themes are the built-in themes.
+var Themes = map[string]Theme{
+	"default": {
+		Normal:    lipgloss.AdaptiveColor{Light: "#1A1A1A", Dark: "#DDDDDD"},
+		Inverse:   lipgloss.AdaptiveColor{Light: "#DDDDDD", Dark: "#1A1A1A"},
+		Contrast:  lipgloss.AdaptiveColor{Light: "#000", Dark: "#FFF"},
+		Title:     lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"},
+		Danger:    lipgloss.AdaptiveColor{Light: "#ff005f", Dark: "#ff005f"},
+		Success:   lipgloss.AdaptiveColor{Light: "#00835a", Dark: "#00ffaf"},
+		Warning:   lipgloss.AdaptiveColor{Light: "#e9a000", Dark: "#ffaf00"},
+		Dimmed:    lipgloss.AdaptiveColor{Light: "#7c737c", Dark: "#777777"},
+		Muted:     lipgloss.AdaptiveColor{Light: "#6e5e6e", Dark: "#4D4D4D"},
+		Primary:   lipgloss.AdaptiveColor{Light: "#008448", Dark: "#20F394"},
+		Secondary: lipgloss.AdaptiveColor{Light: "#007c88", Dark: "#00E5FA"},
+	},
+	"high-contrast": {
+		Normal:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Inverse:   lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Contrast:  lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"},
+		Title:     lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"},
+		Danger:    lipgloss.AdaptiveColor{Light: "#D70000", Dark: "#FF5F5F"},
+		Success:   lipgloss.AdaptiveColor{Light: "#005F00", Dark: "#5FFF5F"},
+		Warning:   lipgloss.AdaptiveColor{Light: "#875F00", Dark: "#FFFF00"},
+		Dimmed:    lipgloss.AdaptiveColor{Light: "#303030", Dark: "#D0D0D0"},
+		Muted:     lipgloss.AdaptiveColor{Light: "#4E4E4E", Dark: "#A8A8A8"},
+		Primary:   lipgloss.AdaptiveColor{Light: "#0000D7", Dark: "#00FFFF"},
+		Secondary: lipgloss.AdaptiveColor{Light: "#870087", Dark: "#FF87FF"},
+	},
+	"solarized": {
+		Normal:    lipgloss.AdaptiveColor{Light: "#657b83", Dark: "#839496"},
+		Inverse:   lipgloss.AdaptiveColor{Light: "#eee8d5", Dark: "#073642"},
+		Contrast:  lipgloss.AdaptiveColor{Light: "#586e75", Dark: "#93a1a1"},
+		Title:     lipgloss.AdaptiveColor{Light: "#fdf6e3", Dark: "#002b36"},
+		Danger:    lipgloss.AdaptiveColor{Light: "#dc322f", Dark: "#dc322f"},
+		Success:   lipgloss.AdaptiveColor{Light: "#859900", Dark: "#859900"},
+		Warning:   lipgloss.AdaptiveColor{Light: "#b58900", Dark: "#b58900"},
+		Dimmed:    lipgloss.AdaptiveColor{Light: "#93a1a1", Dark: "#586e75"},
+		Muted:     lipgloss.AdaptiveColor{Light: "#eee8d5", Dark: "#073642"},
+		Primary:   lipgloss.AdaptiveColor{Light: "#268bd2", Dark: "#268bd2"},
+		Secondary: lipgloss.AdaptiveColor{Light: "#2aa198", Dark: "#2aa198"},
+	},
+	"monochrome": {},
+}
+
+func (t *Theme) named() map[string]*lipgloss.AdaptiveColor {
+	return map[string]*lipgloss.AdaptiveColor{
+		"normal":    &t.Normal,
+		"inverse":   &t.Inverse,
+		"contrast":  &t.Contrast,
+		"title":     &t.Title,
+		"danger":    &t.Danger,
+		"success":   &t.Success,
+		"warning":   &t.Warning,
+		"dimmed":    &t.Dimmed,
+		"muted":     &t.Muted,
+		"primary":   &t.Primary,
+		"secondary": &t.Secondary,
+	}
+}
+
+// With returns the theme with the colors replaced by name.
+func (t Theme) With(colors map[string]lipgloss.AdaptiveColor) Theme {
+	named := t.named()
+	for name, c := range colors {
+		if p, ok := named[name]; ok {
+			*p = c
+		}
+	}
+	return t
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	return slices.Sorted(maps.Keys(Themes))
+}
+
+// ColorNames returns the names of the colors of a theme.
+func ColorNames() []string {
+	return slices.Sorted(maps.Keys((&Theme{}).named()))
+}
+
+func init() {
+	SetTheme(Themes["default"])
+}
+
+// SetTheme sets the colors and styles used by the views created afterwards.
+func SetTheme(t Theme) {
+	ColorNormal = t.Normal
+	ColorNormalInv = t.Inverse
+	ColorBW = t.Contrast
+	ColorTitle = t.Title
+	ColorDanger = t.Danger
+	ColorSuccess = t.Success
+	ColorWarning = t.Warning
+	ColorDimmed = t.Dimmed
+	ColorMuted = t.Muted
+	ColorPrimary = t.Primary
+	ColorSecondary = t.Secondary
+
+	PrimaryTitleStyle = titleStyle(ColorPrimary)
+	SecondaryTitleStyle = titleStyle(ColorSecondary)
+	WarningTitleStyle = titleStyle(ColorWarning)
+	NormalTextStyle = lipgloss.NewStyle().Foreground(ColorNormal)
+	DangerTextStyle = lipgloss.NewStyle().Foreground(ColorDanger)
+	SuccessTextStyle = lipgloss.NewStyle().Foreground(ColorSuccess)
+	WarningTextStyle = lipgloss.NewStyle().Foreground(ColorWarning)
+	DimmedTextStyle = lipgloss.NewStyle().Foreground(ColorDimmed)
+	MutedTextStyle = lipgloss.NewStyle().Foreground(ColorMuted)
+	PrimaryTextStyle = lipgloss.NewStyle().Foreground(ColorPrimary)
+	SecondaryTextStyle = lipgloss.NewStyle().Foreground(ColorSecondary)
+	SpinnerStyle = lipgloss.NewStyle().Foreground(ColorPrimary)
+}
+
+func titleStyle(bg lipgloss.AdaptiveColor) lipgloss.Style {
+	s := lipgloss.NewStyle().Padding(0, 1)
+	if bg == (lipgloss.AdaptiveColor{}) {
+		return s.Reverse(true)
+	}
+	return s.Background(bg).Foreground(ColorTitle)
+}
+
+// hasDarkBackground is the detected terminal background. It is queried once,
+// before it is set explicitly and before the TUI takes over the terminal.
+var hasDarkBackground = sync.OnceValue(lipgloss.HasDarkBackground)
+
+// SetBackground picks the light or dark variant of the colors, auto uses
+// the detected terminal background.
+func SetBackground(background string) {
+	detected := hasDarkBackground()
+	switch background {
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	default:
+		lipgloss.SetHasDarkBackground(detected)
+	}
+}
+
+// Resolve returns the variant of c for the terminal background, for the
+// components that take a plain color.
+func Resolve(c lipgloss.AdaptiveColor) string {
+	if lipgloss.HasDarkBackground() {
+		return c.Dark
+	}
+	return c.Light
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func checkColor(c string) error {
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	if !hexColor.MatchString(c) {
+		return fmt.Errorf("%q is neither a hex code like #20F394 nor an ANSI color number", c)
+	}
+	return nil
+}
+
+// CheckColors validates colors set in a config or theme file, by name, as
+// hex codes or ANSI color numbers.
+func CheckColors(colors map[string]lipgloss.AdaptiveColor) error {
+	named := (&Theme{}).named()
+	for name, c := range colors {
+		if _, ok := named[name]; !ok {
+			return fmt.Errorf("unknown color %q, expected one of: %s", name, strings.Join(ColorNames(), ", "))
+		}
+		for _, v := range []string{c.Light, c.Dark} {
+			if err := checkColor(v); err != nil {
+				return fmt.Errorf("color %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package constants
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolve(t *testing.T) {
+	dark := lipgloss.HasDarkBackground()
+	t.Cleanup(func() { lipgloss.SetHasDarkBackground(dark) })
+
+	c := lipgloss.AdaptiveColor{Light: "#008448", Dark: "#20F394"}
+	for _, tt := range []struct {
+		dark bool
+		want string
+	}{
+		{false, "#008448"},
+		{true, "#20F394"},
+	} {
+		lipgloss.SetHasDarkBackground(tt.dark)
+		if got := Resolve(c); got != tt.want {
+			t.Errorf("Resolve() on a dark background %t = %q, want %q", tt.dark, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package exitnodelist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+)
+
+type listItem struct {
+	title, desc string
+	id          tailcfg.StableNodeID
+}
+
+func (i listItem) Title() string       { return i.title }
+func (i listItem) Description() string { return i.desc }
+func (i listItem) FilterValue() string { return i.title + " " + i.desc }
+
+type Model struct {
+	tailStatus *ipnstate.Status
+	prefs      *ipn.Prefs
+	list       list.Model
+	keyMap     keymap.KeyMap
+	w          int
+	h          int
+}
+
+type BackMsg bool
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	m.list.SetSize(w, h)
+}
+
+func (m Model) currentExitNode() tailcfg.StableNodeID {
+	if m.prefs != nil {
+		return m.prefs.ExitNodeID
+	}
+	if m.tailStatus != nil && m.tailStatus.ExitNodeStatus != nil {
+		return m.tailStatus.ExitNodeStatus.ID
+	}
+	return ""
+}
+
+func (m Model) allowLANAccess() bool {
+	return m.prefs != nil && m.prefs.ExitNodeAllowLANAccess
+}
+
+func (m *Model) updateKeybindings() {
+	filtering := m.list.FilterState() == list.Filtering
+	m.keyMap.Enter.SetEnabled(!filtering && m.list.SelectedItem() != nil)
+	m.keyMap.Back.SetEnabled(!filtering)
+	m.keyMap.AllowLAN.SetEnabled(!filtering)
+	m.keyMap.ClearExitNode.SetEnabled(!filtering && m.currentExitNode() != "")
+	m.list.KeyMap.NextPage.SetEnabled(false)
+	m.list.KeyMap.PrevPage.SetEnabled(false)
+	m.list.KeyMap.Quit.SetEnabled(false)
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	switch {
+	case key.Matches(msg, m.keyMap.Enter):
+		id := m.list.SelectedItem().(listItem).id
+		cmds = append(cmds, func() tea.Msg { return ts.SetExitNodeMsg(id) })
+	case key.Matches(msg, m.keyMap.ClearExitNode):
+		cmds = append(cmds, func() tea.Msg { return ts.SetExitNodeMsg("") })
+	case key.Matches(msg, m.keyMap.AllowLAN):
+		allow := !m.allowLANAccess()
+		cmds = append(cmds, func() tea.Msg { return ts.AllowLANAccessMsg(allow) })
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	}
+	return m, cmds
+}
+
+func (m *Model) getItems() []list.Item {
+	items := []list.Item{}
+	if m.tailStatus == nil {
+		return items
+	}
+
+	current := m.currentExitNode()
+	none := "None"
+	if current == "" {
+		none += " " + constants.SuccessTextStyle.Bold(true).Render("[in use]")
+	}
+	items = append(items, listItem{title: none, desc: "- route traffic directly"})
+
+	peers := []*ipnstate.PeerStatus{}
+	for _, v := range m.tailStatus.Peer {
+		if v.ExitNodeOption || v.ID == current {
+			peers = append(peers, v)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return strings.ToLower(peers[i].HostName) < strings.ToLower(peers[j].HostName)
+	})
+
+	for _, v := range peers {
+		state := constants.DangerTextStyle.Render("●")
+		if v.Online {
+			state = constants.SuccessTextStyle.Render("●")
+		}
+		inUse := ""
+		if v.ID == current {
+			inUse = constants.SuccessTextStyle.Bold(true).Render("[in use]")
+		}
+		os := constants.NormalTextStyle.Render(v.OS)
+		title := fmt.Sprintf("%s %s %s %s", v.HostName, state, os, inUse)
+		var details []string
+		if v.Location != nil {
+			details = append(details, v.Location.City+", "+v.Location.Country)
+		}
+		for _, ip := range v.TailscaleIPs {
+			details = append(details, ip.String())
+		}
+		details = append(details, v.DNSName)
+		desc := "- " + strings.Join(details, " | ")
+		items = append(items, listItem{title: title, desc: desc, id: v.ID})
+	}
+	return items
+}
+
+func (m *Model) updateTitle() {
+	lan := constants.DangerTextStyle.Render("blocked")
+	if m.allowLANAccess() {
+		lan = constants.SuccessTextStyle.Render("allowed")
+	}
+	m.list.SetStatusBarItemName("exit node", "exit nodes")
+	m.list.NewStatusMessage(constants.DimmedTextStyle.Render("LAN access: ") + lan)
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.StatusDataMsg:
+		m.tailStatus = msg
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+	case ts.PrefsDataMsg:
+		m.prefs = msg
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+		m.updateTitle()
+	case tea.KeyMsg:
+		var kcmds []tea.Cmd
+		m, kcmds = m.keyBindingsHandler(msg)
+		cmds = append(cmds, kcmds...)
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	m.updateKeybindings()
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	return m.list.View()
+}
+
+func New(status *ipnstate.Status, prefs *ipn.Prefs, w, h int) Model {
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = lipgloss.NewStyle().Foreground(constants.ColorNormal).Padding(0, 0, 0, 2)
+	d.Styles.NormalDesc = d.Styles.NormalTitle.Foreground(constants.ColorDimmed)
+	d.Styles.SelectedTitle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(constants.ColorPrimary).
+		Foreground(constants.ColorPrimary).
+		Padding(0, 0, 0, 1)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle
+	d.Styles.DimmedTitle = constants.DimmedTextStyle.Padding(0, 0, 0, 2)
+	d.Styles.DimmedDesc = d.Styles.DimmedTitle.Foreground(constants.ColorMuted)
+	d.SetHeight(2)
+	d.SetSpacing(1)
+	m := Model{
+		list:       list.New([]list.Item{}, d, w, h),
+		keyMap:     keymap.NewKeyMap(keymap.ViewExitNodes),
+		tailStatus: status,
+		prefs:      prefs,
+		w:          w,
+		h:          h,
+	}
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewExitNodes)
+	m.keyMap.Enter.SetHelp(m.keyMap.Enter.Help().Key, "use exit node")
+	m.list.SetItems(m.getItems())
+	for i, item := range m.list.Items() {
+		if item.(listItem).id == m.currentExitNode() {
+			m.list.Select(i)
+		}
+	}
+
+	m.list.Title = "Exit Nodes"
+	m.list.Styles.Title = constants.PrimaryTitleStyle
+	m.list.FilterInput.PromptStyle = constants.PrimaryTextStyle
+	m.list.FilterInput.Cursor.Style = constants.PrimaryTextStyle
+	m.updateTitle()
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.Enter,
+			m.keyMap.AllowLAN,
+			m.keyMap.Back,
+		}
+	}
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.Enter,
+			m.keyMap.ClearExitNode,
+			m.keyMap.AllowLAN,
+			m.keyMap.Back,
+		}
+	}
+	m.updateKeybindings()
+	return m
+}
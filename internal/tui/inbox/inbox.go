@@ -0,0 +1,249 @@
+package inbox
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/client/tailscale/apitype"
+)
+
+type listItem struct {
+	title, desc string
+	file        apitype.WaitingFile
+}
+
+func (i listItem) Title() string       { return i.title }
+func (i listItem) Description() string { return i.desc }
+func (i listItem) FilterValue() string { return i.title }
+
+type Model struct {
+	files      []apitype.WaitingFile
+	list       list.Model
+	keyMap     keymap.KeyMap
+	input      textinput.Model
+	saving     *apitype.WaitingFile
+	discarding *apitype.WaitingFile
+	inputErr   string
+	w          int
+	h          int
+}
+
+type BackMsg bool
+
+const inputH = 3
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	if m.saving != nil {
+		h -= inputH
+	}
+	m.list.SetSize(w, h)
+}
+
+func (m *Model) updateKeybindings() {
+	filtering := m.list.FilterState() == list.Filtering
+	hasItem := m.list.SelectedItem() != nil
+	m.keyMap.SaveFile.SetEnabled(!filtering && hasItem)
+	m.keyMap.Delete.SetEnabled(!filtering && hasItem)
+	m.keyMap.Back.SetEnabled(!filtering)
+	m.list.KeyMap.NextPage.SetEnabled(false)
+	m.list.KeyMap.PrevPage.SetEnabled(false)
+	m.list.KeyMap.Quit.SetEnabled(false)
+}
+
+func (m *Model) startSaving(file apitype.WaitingFile) tea.Cmd {
+	m.saving = &file
+	m.inputErr = ""
+	m.input.SetValue(ts.DefaultSaveDir())
+	m.input.CursorEnd()
+	m.SetSize(m.w, m.h)
+	return m.input.Focus()
+}
+
+func (m *Model) stopSaving() {
+	m.saving = nil
+	m.input.Blur()
+	m.SetSize(m.w, m.h)
+}
+
+func (m Model) inputHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.stopSaving()
+	case tea.KeyEnter:
+		dir := ts.ExpandHome(m.input.Value())
+		fi, err := os.Stat(dir)
+		if err == nil && !fi.IsDir() {
+			err = fmt.Errorf("%s is not a directory", dir)
+		}
+		if err != nil {
+			m.inputErr = err.Error()
+			break
+		}
+		name := m.saving.Name
+		m.stopSaving()
+		cmds = append(cmds, func() tea.Msg { return ts.SaveFileMsg{Name: name, Dir: dir} })
+	default:
+		m.inputErr = ""
+		m.input, cmd = m.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, cmds
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.discarding != nil {
+		switch {
+		case key.Matches(msg, m.keyMap.Confirm):
+			name := m.discarding.Name
+			cmds = append(cmds, func() tea.Msg { return ts.DiscardFileMsg(name) })
+			m.discarding = nil
+		case key.Matches(msg, m.keyMap.Cancel):
+			m.discarding = nil
+		}
+		m.updateStatus()
+		return m, cmds
+	}
+	switch {
+	case key.Matches(msg, m.keyMap.SaveFile):
+		cmds = append(cmds, m.startSaving(m.list.SelectedItem().(listItem).file))
+	case key.Matches(msg, m.keyMap.Delete):
+		file := m.list.SelectedItem().(listItem).file
+		m.discarding = &file
+		m.updateStatus()
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	}
+	return m, cmds
+}
+
+func (m *Model) getItems() []list.Item {
+	items := []list.Item{}
+	for _, f := range m.files {
+		items = append(items, listItem{title: f.Name, desc: "- " + ts.FormatBytes(f.Size), file: f})
+	}
+	return items
+}
+
+func (m *Model) updateStatus() {
+	if m.discarding != nil {
+		m.list.NewStatusMessage(constants.WarningTextStyle.Render(
+			fmt.Sprintf("Discard %s? (y/n)", m.discarding.Name)))
+		return
+	}
+	m.list.NewStatusMessage("")
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.WaitingFilesMsg:
+		m.files = msg
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+	case tea.KeyMsg:
+		var kcmds []tea.Cmd
+		if m.saving != nil {
+			m, kcmds = m.inputHandler(msg)
+			return m, tea.Batch(kcmds...)
+		}
+		if m.list.FilterState() != list.Filtering {
+			discarding := m.discarding != nil
+			m, kcmds = m.keyBindingsHandler(msg)
+			cmds = append(cmds, kcmds...)
+			if discarding {
+				return m, tea.Batch(cmds...)
+			}
+		}
+	default:
+		if m.saving != nil {
+			m.input, cmd = m.input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	m.updateKeybindings()
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.saving == nil {
+		return m.list.View()
+	}
+	errView := ""
+	if m.inputErr != "" {
+		errView = constants.DangerTextStyle.Render(m.inputErr)
+	}
+	inputView := lipgloss.NewStyle().Margin(0, 2).Height(inputH).Render(
+		lipgloss.JoinVertical(lipgloss.Left, m.input.View(), errView))
+	return lipgloss.JoinVertical(lipgloss.Left, m.list.View(), inputView)
+}
+
+func New(files []apitype.WaitingFile, w, h int) Model {
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = lipgloss.NewStyle().Foreground(constants.ColorNormal).Padding(0, 0, 0, 2)
+	d.Styles.NormalDesc = d.Styles.NormalTitle.Foreground(constants.ColorDimmed)
+	d.Styles.SelectedTitle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(constants.ColorPrimary).
+		Foreground(constants.ColorPrimary).
+		Padding(0, 0, 0, 1)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle
+	d.Styles.DimmedTitle = constants.DimmedTextStyle.Padding(0, 0, 0, 2)
+	d.Styles.DimmedDesc = d.Styles.DimmedTitle.Foreground(constants.ColorMuted)
+	d.SetHeight(2)
+	d.SetSpacing(1)
+	m := Model{
+		files:  files,
+		keyMap: keymap.NewKeyMap(keymap.ViewInbox),
+		input:  textinput.New(),
+		w:      w,
+		h:      h,
+	}
+	m.keyMap.Delete.SetHelp(m.keyMap.Delete.Help().Key, "discard")
+	m.list = list.New(m.getItems(), d, w, h)
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewInbox)
+	m.input.Prompt = "Save to: "
+	m.input.PromptStyle = constants.PrimaryTextStyle
+	m.input.Cursor.Style = constants.PrimaryTextStyle
+
+	m.list.Title = "Taildrop Inbox"
+	m.list.Styles.Title = constants.PrimaryTitleStyle
+	m.list.FilterInput.PromptStyle = constants.PrimaryTextStyle
+	m.list.FilterInput.Cursor.Style = constants.PrimaryTextStyle
+	m.list.SetStatusBarItemName("file", "files")
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.SaveFile,
+			m.keyMap.Delete,
+			m.keyMap.Back,
+		}
+	}
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.SaveFile,
+			m.keyMap.Delete,
+			m.keyMap.Back,
+		}
+	}
+	m.updateKeybindings()
+	return m
+}
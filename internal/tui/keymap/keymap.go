@@ -1,7 +1,14 @@
 package keymap
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 )
 
 type KeyMap struct {
@@ -9,6 +16,39 @@ type KeyMap struct {
 	CopyIpv6      key.Binding
 	CopyDNSName   key.Binding
 	Refresh       key.Binding
+	ExitNodes     key.Binding
+	AllowLAN      key.Binding
+	ClearExitNode key.Binding
+	AddRoute      key.Binding
+	RemoveRoute   key.Binding
+	AcceptRoutes  key.Binding
+	Prefs         key.Binding
+	Profiles      key.Binding
+	NewProfile    key.Binding
+	Delete        key.Binding
+	CopyURL       key.Binding
+	Inbox         key.Binding
+	SaveFile      key.Binding
+	Netcheck      key.Binding
+	Sort          key.Binding
+	ReverseSort   key.Binding
+	Group         key.Binding
+	Collapse      key.Binding
+	SavedFilter   key.Binding
+	SaveFilter    key.Binding
+	Tags          key.Binding
+	Probe         key.Binding
+	Export        key.Binding
+	ScrollUp      key.Binding
+	ScrollDown    key.Binding
+	Stop          key.Binding
+	PingType      key.Binding
+	NextField     key.Binding
+	PrevField     key.Binding
+	Toggle        key.Binding
+	Save          key.Binding
+	Confirm       key.Binding
+	Cancel        key.Binding
 	Enter         key.Binding
 	Back          key.Binding
 	Quit          key.Binding
@@ -18,18 +58,23 @@ type KeyMap struct {
 }
 
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.CopyIpv4, k.Enter, k.Back, k.Quit, k.ShowFullHelp}
+	return []key.Binding{k.CopyIpv4, k.Enter, k.PingType, k.Stop, k.Back, k.Quit, k.ShowFullHelp}
 }
 
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.CopyIpv4, k.CopyIpv6, k.CopyDNSName},
-		{k.Enter, k.Back, k.Quit, k.CloseFullHelp},
+		{k.Enter, k.PingType, k.Stop, k.Back, k.Quit, k.CloseFullHelp},
 	}
 }
 
-func NewKeyMap() KeyMap {
-	return KeyMap{
+// NewKeyMap returns the keys of view, with the ones set in the config file.
+func NewKeyMap(view string) KeyMap {
+	return newKeyMap(view, overrides)
+}
+
+func newKeyMap(view string, o Overrides) KeyMap {
+	k := KeyMap{
 		Enter: key.NewBinding(
 			key.WithKeys("enter", "right", "l"),
 			key.WithHelp("enter/→/l", "details"),
@@ -54,6 +99,140 @@ func NewKeyMap() KeyMap {
 			key.WithKeys("r"),
 			key.WithHelp("r", "refresh"),
 		),
+		ExitNodes: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "exit nodes"),
+		),
+		AllowLAN: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "toggle LAN access"),
+		),
+		ClearExitNode: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "clear exit node"),
+		),
+		AddRoute: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "add route"),
+		),
+		RemoveRoute: key.NewBinding(
+			key.WithKeys("d", "delete"),
+			key.WithHelp("d", "remove route"),
+		),
+		AcceptRoutes: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "toggle accept routes"),
+		),
+		Prefs: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "preferences"),
+		),
+		Profiles: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "profiles"),
+		),
+		NewProfile: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new profile"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d", "delete"),
+			key.WithHelp("d", "delete"),
+		),
+		CopyURL: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy URL"),
+		),
+		Inbox: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "taildrop inbox"),
+		),
+		SaveFile: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "save file"),
+		),
+		Netcheck: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "netcheck"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort"),
+		),
+		ReverseSort: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "reverse sort"),
+		),
+		Group: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "group by"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "collapse group"),
+		),
+		SavedFilter: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "saved filter"),
+		),
+		SaveFilter: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "save filter"),
+		),
+		Tags: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "filter by tag"),
+		),
+		Probe: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "probe latency"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "export"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Stop: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s/esc", "stop"),
+			key.WithDisabled(),
+		),
+		PingType: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "ping type"),
+			key.WithDisabled(),
+		),
+		NextField: key.NewBinding(
+			key.WithKeys("down", "tab"),
+			key.WithHelp("↓/tab", "next field"),
+		),
+		PrevField: key.NewBinding(
+			key.WithKeys("up", "shift+tab"),
+			key.WithHelp("↑/shift+tab", "previous field"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys(" ", "enter"),
+			key.WithHelp("space", "toggle"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "review changes"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("enter", "y"),
+			key.WithHelp("enter/y", "apply"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc", "n"),
+			key.WithHelp("esc/n", "cancel"),
+		),
 		ShowFullHelp: key.NewBinding(
 			key.WithKeys("?"),
 			key.WithHelp("?", "more"),
@@ -63,9 +242,280 @@ func NewKeyMap() KeyMap {
 			key.WithHelp("?", "close help"),
 		),
 		Quit: key.NewBinding(
-			key.WithKeys("q", "esc"),
+			key.WithKeys("q"),
 			key.WithHelp("q", "quit"),
 		),
 		ForceQuit: key.NewBinding(key.WithKeys("ctrl+c")),
 	}
+	named := k.named()
+	apply(named, viewDefaults[view])
+	apply(named, o[""])
+	apply(named, o[view])
+	return k
+}
+
+// NewListKeyMap returns the keys of the list in view. Help and quit are the
+// ones of the view.
+func NewListKeyMap(view string) list.KeyMap {
+	return newListKeyMap(view, overrides)
+}
+
+func newListKeyMap(view string, o Overrides) list.KeyMap {
+	l := list.DefaultKeyMap()
+	k := newKeyMap(view, o)
+	l.ShowFullHelp, l.CloseFullHelp = k.ShowFullHelp, k.CloseFullHelp
+	l.Quit, l.ForceQuit = k.Quit, k.ForceQuit
+	named := listNamed(&l)
+	apply(named, o[""])
+	apply(named, o[view])
+	return l
+}
+
+// Views that can have their own keys in the config file.
+const (
+	ViewNodes     = "nodes"
+	ViewDetails   = "details"
+	ViewExitNodes = "exit_nodes"
+	ViewRoutes    = "routes"
+	ViewPrefs     = "prefs"
+	ViewProfiles  = "profiles"
+	ViewInbox     = "inbox"
+	ViewNetcheck  = "netcheck"
+	ViewLogin     = "login"
+	ViewTags      = "tags"
+)
+
+// Overrides are keys by view and binding name, the ones under "" are used
+// in every view.
+type Overrides map[string]map[string][]string
+
+// overrides are the keys set in the config file.
+var overrides Overrides
+
+// viewDefaults are the keys that differ from NewKeyMap in a view. Views
+// without a way back quit on esc.
+var viewDefaults = Overrides{
+	ViewNodes:    {"quit": {"q", "esc"}},
+	ViewPrefs:    {"back": {"esc"}},
+	ViewNetcheck: {"back": {"esc"}},
+	ViewLogin:    {"enter": {"enter"}, "quit": {"q", "esc"}},
+	ViewTags:     {"toggle": {" "}},
+}
+
+var listKeys = []string{"cursor_up", "cursor_down", "go_to_start", "go_to_end", "filter", "clear_filter", "show_full_help", "close_full_help", "force_quit"}
+
+var filterKeys = []string{"cancel_filter", "accept_filter", "force_quit"}
+
+var detailsKeys = []string{"copy_ipv4", "copy_ipv6", "copy_dns_name", "enter", "ping_type", "back", "quit", "show_full_help", "close_full_help", "cursor_up", "cursor_down"}
+
+// views lists the bindings that are active at the same time in each view,
+// one group per mode, to find conflicts.
+var views = map[string][][]string{
+	ViewNodes: {
+		append([]string{"copy_ipv4", "copy_ipv6", "copy_dns_name", "enter", "exit_nodes", "export", "inbox", "netcheck", "prefs", "probe", "profiles", "refresh", "sort", "reverse_sort", "group", "collapse", "saved_filter", "save_filter", "tags", "quit"}, listKeys...),
+		filterKeys,
+	},
+	ViewDetails: {
+		detailsKeys,
+		append([]string{"stop", "cancel"}, detailsKeys...),
+		{"save", "cancel"},
+	},
+	ViewExitNodes: {
+		append([]string{"enter", "back", "allow_lan", "clear_exit_node"}, listKeys...),
+		filterKeys,
+	},
+	ViewRoutes: {
+		append([]string{"accept_routes", "add_route", "remove_route", "back"}, listKeys...),
+		filterKeys,
+	},
+	ViewPrefs: {
+		{"next_field", "prev_field", "toggle", "save", "back"},
+		{"confirm", "cancel"},
+	},
+	ViewProfiles: {
+		append([]string{"enter", "new_profile", "delete", "back"}, listKeys...),
+		filterKeys,
+		{"confirm", "cancel"},
+	},
+	ViewInbox: {
+		append([]string{"save_file", "delete", "back"}, listKeys...),
+		filterKeys,
+		{"confirm", "cancel"},
+	},
+	ViewNetcheck: {
+		{"scroll_up", "scroll_down", "refresh", "sort", "back"},
+	},
+	ViewTags: {
+		append([]string{"toggle", "enter", "back"}, listKeys...),
+		filterKeys,
+	},
+	ViewLogin: {
+		{"copy_url", "enter", "profiles", "quit"},
+	},
+}
+
+// shared are bindings that may use the same keys, as only one of them is
+// enabled at a time or one of them is matched first on purpose.
+var shared = [][2]string{
+	{"show_full_help", "close_full_help"},
+	{"clear_filter", "quit"},
+	{"clear_filter", "back"},
+	{"cancel", "back"},
+}
+
+func (k *KeyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"copy_ipv4":       &k.CopyIpv4,
+		"copy_ipv6":       &k.CopyIpv6,
+		"copy_dns_name":   &k.CopyDNSName,
+		"refresh":         &k.Refresh,
+		"exit_nodes":      &k.ExitNodes,
+		"allow_lan":       &k.AllowLAN,
+		"clear_exit_node": &k.ClearExitNode,
+		"add_route":       &k.AddRoute,
+		"remove_route":    &k.RemoveRoute,
+		"accept_routes":   &k.AcceptRoutes,
+		"prefs":           &k.Prefs,
+		"profiles":        &k.Profiles,
+		"new_profile":     &k.NewProfile,
+		"delete":          &k.Delete,
+		"copy_url":        &k.CopyURL,
+		"inbox":           &k.Inbox,
+		"save_file":       &k.SaveFile,
+		"netcheck":        &k.Netcheck,
+		"sort":            &k.Sort,
+		"reverse_sort":    &k.ReverseSort,
+		"group":           &k.Group,
+		"collapse":        &k.Collapse,
+		"saved_filter":    &k.SavedFilter,
+		"save_filter":     &k.SaveFilter,
+		"tags":            &k.Tags,
+		"probe":           &k.Probe,
+		"export":          &k.Export,
+		"scroll_up":       &k.ScrollUp,
+		"scroll_down":     &k.ScrollDown,
+		"stop":            &k.Stop,
+		"ping_type":       &k.PingType,
+		"next_field":      &k.NextField,
+		"prev_field":      &k.PrevField,
+		"toggle":          &k.Toggle,
+		"save":            &k.Save,
+		"confirm":         &k.Confirm,
+		"cancel":          &k.Cancel,
+		"enter":           &k.Enter,
+		"back":            &k.Back,
+		"quit":            &k.Quit,
+		"force_quit":      &k.ForceQuit,
+		"show_full_help":  &k.ShowFullHelp,
+		"close_full_help": &k.CloseFullHelp,
+	}
+}
+
+// listNamed returns the list bindings that are not in KeyMap.
+func listNamed(l *list.KeyMap) map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"cursor_up":     &l.CursorUp,
+		"cursor_down":   &l.CursorDown,
+		"next_page":     &l.NextPage,
+		"prev_page":     &l.PrevPage,
+		"go_to_start":   &l.GoToStart,
+		"go_to_end":     &l.GoToEnd,
+		"filter":        &l.Filter,
+		"clear_filter":  &l.ClearFilter,
+		"cancel_filter": &l.CancelWhileFiltering,
+		"accept_filter": &l.AcceptWhileFiltering,
+	}
+}
+
+// apply sets the keys of the bindings in named, skipping the others.
+func apply(named map[string]*key.Binding, keys map[string][]string) {
+	for name, keys := range keys {
+		b, ok := named[name]
+		if !ok {
+			continue
+		}
+		b.SetKeys(keys...)
+		help := strings.Join(keys, "/")
+		if help == " " {
+			help = "space"
+		}
+		b.SetHelp(help, b.Help().Desc)
+	}
+}
+
+func allNamed(k *KeyMap, l *list.KeyMap) map[string]*key.Binding {
+	named := k.named()
+	maps.Copy(named, listNamed(l))
+	return named
+}
+
+// Names returns the names of the bindings used in the config file.
+func Names() []string {
+	return slices.Sorted(maps.Keys(allNamed(&KeyMap{}, &list.KeyMap{})))
+}
+
+// Views returns the names of the views used in the config file.
+func Views() []string {
+	return slices.Sorted(maps.Keys(views))
+}
+
+func inView(view, name string) bool {
+	for _, group := range views[view] {
+		if slices.Contains(group, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOverrides validates keys set in the config file, and reports keys
+// bound to more than one binding active at the same time in a view.
+func CheckOverrides(o Overrides) error {
+	names := allNamed(&KeyMap{}, &list.KeyMap{})
+	for _, view := range slices.Sorted(maps.Keys(o)) {
+		if _, ok := views[view]; view != "" && !ok {
+			return fmt.Errorf("unknown view %q, expected one of: %s", view, strings.Join(Views(), ", "))
+		}
+		for name, keys := range o[view] {
+			if _, ok := names[name]; !ok {
+				return fmt.Errorf("unknown key binding %q, expected one of: %s", name, strings.Join(Names(), ", "))
+			}
+			if view != "" && !inView(view, name) {
+				return fmt.Errorf("key binding %s is not used in the %s view", name, view)
+			}
+			if len(keys) == 0 || slices.Contains(keys, "") {
+				return fmt.Errorf("key binding %s: keys must not be empty", name)
+			}
+		}
+	}
+	var conflicts []string
+	for _, view := range Views() {
+		k, l := newKeyMap(view, o), newListKeyMap(view, o)
+		named := allNamed(&k, &l)
+		for _, group := range views[view] {
+			for i, a := range group {
+				for _, b := range group[i+1:] {
+					if slices.Contains(shared, [2]string{a, b}) || slices.Contains(shared, [2]string{b, a}) {
+						continue
+					}
+					for _, s := range named[a].Keys() {
+						conflict := fmt.Sprintf("%q is bound to both %s and %s in the %s view", s, a, b, view)
+						if slices.Contains(named[b].Keys(), s) && !slices.Contains(conflicts, conflict) {
+							conflicts = append(conflicts, conflict)
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return errors.New(strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// SetOverrides makes NewKeyMap and NewListKeyMap use the keys set in the
+// config file.
+func SetOverrides(o Overrides) {
+	overrides = o
 }
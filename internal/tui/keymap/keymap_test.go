@@ -0,0 +1,104 @@
+package keymap
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		o    Overrides
+		err  string
+	}{
+		{name: "defaults"},
+		{name: "every view", o: Overrides{"": {"refresh": {"f5"}}}},
+		{name: "one view", o: Overrides{ViewPrefs: {"back": {"q"}}}},
+		{name: "list key", o: Overrides{ViewNodes: {"cursor_down": {"down", "n"}}}},
+		{
+			name: "unknown view",
+			o:    Overrides{"menu": {"back": {"q"}}},
+			err:  `unknown view "menu", expected one of: details, exit_nodes, inbox, login, netcheck, nodes, prefs, profiles, routes, tags`,
+		},
+		{
+			name: "unknown binding",
+			o:    Overrides{"": {"jump": {"J"}}},
+			err:  `unknown key binding "jump", expected one of: ` + strings.Join(Names(), ", "),
+		},
+		{
+			name: "not in view",
+			o:    Overrides{ViewNetcheck: {"copy_ipv4": {"c"}}},
+			err:  "key binding copy_ipv4 is not used in the netcheck view",
+		},
+		{
+			name: "empty keys",
+			o:    Overrides{ViewNodes: {"refresh": {}}},
+			err:  "key binding refresh: keys must not be empty",
+		},
+		{
+			name: "empty key",
+			o:    Overrides{ViewNodes: {"refresh": {""}}},
+			err:  "key binding refresh: keys must not be empty",
+		},
+		{
+			name: "conflict",
+			o:    Overrides{ViewNodes: {"refresh": {"s"}}},
+			err:  `"s" is bound to both refresh and sort in the nodes view`,
+		},
+		{
+			name: "conflict while pinging",
+			o:    Overrides{ViewDetails: {"copy_ipv4": {"n"}}},
+			err:  `"n" is bound to both cancel and copy_ipv4 in the details view`,
+		},
+		{
+			name: "conflict in file picker",
+			o:    Overrides{ViewDetails: {"save": {"esc"}}},
+			err:  `"esc" is bound to both save and cancel in the details view`,
+		},
+		{
+			name: "conflict in every view",
+			o:    Overrides{"": {"back": {"q"}}},
+			err:  `"q" is bound to both back and quit in the details view`,
+		},
+		{name: "shared", o: Overrides{ViewDetails: {"cancel": {"esc"}}}},
+	}
+	for _, tt := range tests {
+		err := CheckOverrides(tt.o)
+		if got := errString(err); got != tt.err {
+			t.Errorf("%s: CheckOverrides() = %q, want %q", tt.name, got, tt.err)
+		}
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		name string
+		view string
+		o    Overrides
+		keys []string
+		help string
+	}{
+		{name: "back", view: ViewDetails, keys: []string{"esc", "left", "h"}, help: "esc/←/h"},
+		{name: "back", view: ViewPrefs, keys: []string{"esc"}, help: "esc"},
+		{name: "back", view: ViewPrefs, o: Overrides{ViewPrefs: {"back": {"q"}}}, keys: []string{"q"}, help: "q"},
+		{name: "back", view: ViewNetcheck, o: Overrides{"": {"back": {"backspace"}}}, keys: []string{"backspace"}, help: "backspace"},
+		{name: "enter", view: ViewLogin, keys: []string{"enter"}, help: "enter"},
+		{name: "quit", view: ViewLogin, keys: []string{"q", "esc"}, help: "q/esc"},
+		{name: "toggle", view: ViewTags, keys: []string{" "}, help: "space"},
+	}
+	for _, tt := range tests {
+		k := newKeyMap(tt.view, tt.o)
+		b := k.named()[tt.name]
+		if !slices.Equal(b.Keys(), tt.keys) || b.Help().Key != tt.help {
+			t.Errorf("%s in %s: keys %q, help %q, want %q, %q", tt.name, tt.view, b.Keys(), b.Help().Key, tt.keys, tt.help)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,179 @@
+package login
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/types"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skip2/go-qrcode"
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+)
+
+type Model struct {
+	tailStatus *ipnstate.Status
+	authURL    string
+	qr         string
+	starting   bool
+	err        string
+	keyMap     keymap.KeyMap
+	help       help.Model
+	w, h       int
+}
+
+type helpKeys struct {
+	keyMap keymap.KeyMap
+}
+
+func (k helpKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.keyMap.Enter, k.keyMap.CopyURL, k.keyMap.Profiles, k.keyMap.Quit}
+}
+
+func (k helpKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+}
+
+func (m Model) needsMachineAuth() bool {
+	return m.tailStatus != nil && m.tailStatus.BackendState == ipn.NeedsMachineAuth.String()
+}
+
+func (m *Model) setAuthURL(url string) {
+	if url == m.authURL {
+		return
+	}
+	m.authURL = url
+	m.qr = ""
+	m.starting = false
+	if url == "" {
+		return
+	}
+	q, err := qrcode.New(url, qrcode.Medium)
+	if err != nil {
+		m.err = fmt.Sprintf("Could not render QR code: %s", err)
+		return
+	}
+	m.qr = q.ToSmallString(false)
+}
+
+func (m *Model) updateKeybindings() {
+	m.keyMap.Enter.SetEnabled(!m.needsMachineAuth() && !m.starting)
+	m.keyMap.CopyURL.SetEnabled(m.authURL != "")
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	switch {
+	case key.Matches(msg, m.keyMap.Enter):
+		cmds = append(cmds, func() tea.Msg { return ts.LoginMsg(true) })
+	case key.Matches(msg, m.keyMap.CopyURL):
+		status := fmt.Sprintf("Copied \"%s\"!", constants.PrimaryTextStyle.Underline(true).Render(m.authURL))
+		if err := clipboard.WriteAll(m.authURL); err != nil {
+			status = fmt.Sprintf("Sorry, error occured: %s", err)
+		}
+		cmds = append(cmds, types.NewStatusMsg(status))
+	case key.Matches(msg, m.keyMap.Profiles):
+		cmds = append(cmds, func() tea.Msg { return types.ShowProfilesMsg(true) })
+	case key.Matches(msg, m.keyMap.Quit):
+		cmds = append(cmds, tea.Quit)
+	}
+	return m, cmds
+}
+
+// Init starts an interactive login unless the device is only waiting for
+// admin approval.
+func (m Model) Init() tea.Cmd {
+	if m.needsMachineAuth() || m.authURL != "" {
+		return nil
+	}
+	return func() tea.Msg { return ts.LoginMsg(true) }
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.StatusDataMsg:
+		m.tailStatus = msg
+		if msg.AuthURL != "" {
+			m.setAuthURL(msg.AuthURL)
+		}
+	case ts.LoginMsg:
+		m.starting = true
+		m.err = ""
+	case ts.AuthURLMsg:
+		m.setAuthURL(string(msg))
+	case ts.LoginErrorMsg:
+		m.starting = false
+		m.err = fmt.Sprintf("Login failed: %s", msg)
+	case tea.KeyMsg:
+		var kcmds []tea.Cmd
+		m, kcmds = m.keyBindingsHandler(msg)
+		cmds = append(cmds, kcmds...)
+	}
+	m.updateKeybindings()
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	var sections []string
+	if m.needsMachineAuth() {
+		sections = append(sections,
+			constants.WarningTitleStyle.Render("Waiting for approval"),
+			"",
+			"This device is logged in, but needs to be approved by an admin of your tailnet.",
+			constants.DimmedTextStyle.Render("It will show up here as soon as it is approved."))
+	} else {
+		sections = append(sections, constants.WarningTitleStyle.Render("Login required"), "")
+		switch {
+		case m.starting && m.authURL == "":
+			sections = append(sections, constants.DimmedTextStyle.Render("Starting login..."))
+		case m.authURL != "":
+			sections = append(sections,
+				"To authenticate, visit:",
+				"",
+				"  "+constants.PrimaryTextStyle.Underline(true).Render(m.authURL),
+				"")
+			used := lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, sections...)) + 4
+			if m.qr != "" && strings.Count(m.qr, "\n")+used <= m.h {
+				sections = append(sections, m.qr)
+			} else if m.qr != "" {
+				sections = append(sections, constants.DimmedTextStyle.Render("Enlarge the terminal to show the QR code."))
+			}
+		default:
+			sections = append(sections, "Press enter to log in.")
+		}
+	}
+	if m.err != "" {
+		sections = append(sections, "", constants.DangerTextStyle.Render(m.err))
+	}
+	sections = append(sections, "", m.help.View(helpKeys{keyMap: m.keyMap}))
+	return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left, sections...))
+}
+
+func New(status *ipnstate.Status, w, h int) Model {
+	m := Model{
+		keyMap:     keymap.NewKeyMap(keymap.ViewLogin),
+		help:       help.New(),
+		tailStatus: status,
+		w:          w,
+		h:          h,
+	}
+	m.keyMap.Enter.SetHelp(m.keyMap.Enter.Help().Key, "log in")
+	if status != nil {
+		m.setAuthURL(status.AuthURL)
+	}
+	m.updateKeybindings()
+	return m
+}
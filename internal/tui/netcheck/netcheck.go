@@ -0,0 +1,287 @@
+package netcheck
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"tailscale.com/ipn/ipnstate"
+)
+
+type sortMode int
+
+const (
+	sortByLatency sortMode = iota
+	sortByCode
+	sortByName
+	sortByPeers
+)
+
+func (s sortMode) String() string {
+	return [...]string{
+		"latency",
+		"code",
+		"name",
+		"peers",
+	}[s]
+}
+
+type Model struct {
+	tailStatus *ipnstate.Status
+	report     *ts.NetcheckReport
+	regions    []ts.DERPRegion
+	running    bool
+	err        string
+	sort       sortMode
+	offset     int
+	keyMap     keymap.KeyMap
+	help       help.Model
+	spinner    spinner.Model
+	w          int
+	h          int
+}
+
+type BackMsg bool
+
+type helpKeys struct {
+	keyMap keymap.KeyMap
+}
+
+func (k helpKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.keyMap.ScrollUp, k.keyMap.ScrollDown, k.keyMap.Sort, k.keyMap.Refresh, k.keyMap.Back}
+}
+
+func (k helpKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// summaryH is the number of lines above and below the region table:
+// title, progress, report lines, legend, help and the blank lines between
+// them.
+const summaryH = 18
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	m.offset = min(m.offset, m.maxOffset())
+}
+
+// tableRows is the number of regions that fit on screen.
+func (m Model) tableRows() int {
+	return max(m.h-summaryH, 1)
+}
+
+func (m Model) maxOffset() int {
+	return max(len(m.regions)-m.tableRows(), 0)
+}
+
+func (m *Model) sortRegions() {
+	if m.report == nil {
+		return
+	}
+	m.regions = ts.DERPRegions(m.report, m.tailStatus)
+	switch m.sort {
+	case sortByCode:
+		slices.SortStableFunc(m.regions, func(a, b ts.DERPRegion) int { return cmp.Compare(a.Code, b.Code) })
+	case sortByName:
+		slices.SortStableFunc(m.regions, func(a, b ts.DERPRegion) int { return cmp.Compare(a.Name, b.Name) })
+	case sortByPeers:
+		slices.SortStableFunc(m.regions, func(a, b ts.DERPRegion) int { return cmp.Compare(b.Peers, a.Peers) })
+	}
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	switch {
+	case key.Matches(msg, m.keyMap.ScrollUp):
+		m.offset = max(m.offset-1, 0)
+	case key.Matches(msg, m.keyMap.ScrollDown):
+		m.offset = min(m.offset+1, m.maxOffset())
+	case key.Matches(msg, m.keyMap.Sort):
+		m.sort = (m.sort + 1) % (sortByPeers + 1)
+		m.offset = 0
+		m.sortRegions()
+	case key.Matches(msg, m.keyMap.Refresh):
+		if !m.running {
+			cmds = append(cmds, m.run())
+		}
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	}
+	return m, cmds
+}
+
+func (m *Model) run() tea.Cmd {
+	m.running = true
+	m.err = ""
+	return tea.Batch(m.spinner.Tick, func() tea.Msg { return ts.RunNetcheckMsg(true) })
+}
+
+func yesNo(v bool) string {
+	if v {
+		return constants.SuccessTextStyle.Render("yes")
+	}
+	return constants.DangerTextStyle.Render("no")
+}
+
+func (m Model) summaryView() string {
+	r := m.report
+	ipv4 := yesNo(false) + constants.DimmedTextStyle.Render(" (no addr found)")
+	if r.GlobalV4.IsValid() {
+		ipv4 = yesNo(true) + ", " + r.GlobalV4.String()
+	}
+	ipv6 := yesNo(false) + constants.DimmedTextStyle.Render(" (unavailable in OS)")
+	switch {
+	case r.GlobalV6.IsValid():
+		ipv6 = yesNo(true) + ", " + r.GlobalV6.String()
+	case r.IPv6:
+		ipv6 = yesNo(true) + constants.DimmedTextStyle.Render(" (no addr found)")
+	case r.OSHasIPv6:
+		ipv6 = yesNo(false) + constants.DimmedTextStyle.Render(" (but OS has support)")
+	}
+	lines := []string{
+		fmt.Sprintf("UDP: %s", yesNo(r.UDP)),
+		fmt.Sprintf("IPv4: %s", ipv4),
+		fmt.Sprintf("IPv6: %s", ipv6),
+		fmt.Sprintf("NAT mapping: %s", ts.NATMapping(r.Report)),
+		fmt.Sprintf("Port mapping: %s", ts.PortMapping(r.Report)),
+		fmt.Sprintf("Nearest DERP: %s", ts.PreferredRegionName(r)),
+	}
+	if v, ok := r.CaptivePortal.Get(); ok && v {
+		lines = append(lines, constants.WarningTextStyle.Render("Captive portal detected"))
+	} else {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Millisecond / 10).String()
+}
+
+func (m Model) tableView() string {
+	end := min(m.offset+m.tableRows(), len(m.regions))
+	visible := m.regions[m.offset:end]
+	var rows [][]string
+	for _, r := range visible {
+		var notes []string
+		if r.Preferred {
+			notes = append(notes, "preferred")
+		}
+		if r.Home {
+			notes = append(notes, "home")
+		}
+		peers := ""
+		if r.Peers > 0 {
+			peers = strconv.Itoa(r.Peers)
+		}
+		rows = append(rows, []string{r.Code, r.Name, formatLatency(r.Latency), formatLatency(r.V4), formatLatency(r.V6), peers, strings.Join(notes, ", ")})
+	}
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+	return table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(constants.MutedTextStyle).
+		BorderRow(false).
+		BorderColumn(false).
+		Headers("Code", "Region", "Latency", "IPv4", "IPv6", "Peers", "").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return cellStyle.Inherit(constants.PrimaryTextStyle).Bold(true)
+			}
+			r := visible[row-1]
+			switch {
+			case r.Home || r.Peers > 0:
+				return cellStyle.Inherit(constants.WarningTextStyle)
+			case r.Preferred:
+				return cellStyle.Inherit(constants.SecondaryTextStyle)
+			case r.Latency == 0:
+				return cellStyle.Inherit(constants.DimmedTextStyle)
+			}
+			return cellStyle.Inherit(constants.NormalTextStyle)
+		}).
+		Render()
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.run()
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.StatusDataMsg:
+		m.tailStatus = msg
+		m.sortRegions()
+	case ts.NetcheckDataMsg:
+		m.running = false
+		m.report = msg
+		m.sortRegions()
+		m.offset = min(m.offset, m.maxOffset())
+	case ts.NetcheckErrorMsg:
+		m.running = false
+		m.err = msg.Error()
+	case spinner.TickMsg:
+		if m.running {
+			m.spinner, cmd = m.spinner.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	case tea.KeyMsg:
+		var kcmds []tea.Cmd
+		m, kcmds = m.keyBindingsHandler(msg)
+		cmds = append(cmds, kcmds...)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	title := constants.PrimaryTitleStyle.Render("Network Check")
+	if m.report != nil {
+		title += constants.DimmedTextStyle.Render(fmt.Sprintf("  sorted by %s", m.sort))
+	}
+	sections := []string{title, ""}
+	switch {
+	case m.running:
+		sections = append(sections, fmt.Sprintf("%s Running netcheck...", m.spinner.View()), "")
+	case m.err != "":
+		sections = append(sections, constants.DangerTextStyle.Render("error: "+m.err), "")
+	}
+	if m.report != nil {
+		legend := constants.WarningTextStyle.Render("■ relay for this device or its peers") + "  " +
+			constants.SecondaryTextStyle.Render("■ preferred")
+		sections = append(sections, m.summaryView(), m.tableView(), legend, "")
+	}
+	sections = append(sections, m.help.View(helpKeys{keyMap: m.keyMap}))
+	return lipgloss.NewStyle().Margin(0, 2).Height(m.h).MaxHeight(m.h).Render(lipgloss.JoinVertical(lipgloss.Left, sections...))
+}
+
+func New(status *ipnstate.Status, w, h int) Model {
+	m := Model{
+		tailStatus: status,
+		keyMap:     keymap.NewKeyMap(keymap.ViewNetcheck),
+		help:       help.New(),
+		spinner:    spinner.New(),
+	}
+	m.spinner.Spinner = spinner.Dot
+	m.spinner.Style = constants.SpinnerStyle
+	m.keyMap.Refresh.SetHelp(m.keyMap.Refresh.Help().Key, "run again")
+	m.keyMap.Back.SetHelp(m.keyMap.Back.Help().Key, "back")
+	m.SetSize(w, h)
+	return m
+}
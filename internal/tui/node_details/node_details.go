@@ -1,8 +1,7 @@
 package nodedetails
 
 import (
-	"context"
-	"errors"
+	"cmp"
 	"fmt"
 	"net/netip"
 	"strings"
@@ -13,26 +12,45 @@ import (
 	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
 	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
 	"github.com/bilguun0203/tailscale-tui/internal/tui/types"
+	"github.com/charmbracelet/bubbles/filepicker"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
 	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/tsaddr"
+	"tailscale.com/tailcfg"
 	tsKey "tailscale.com/types/key"
 )
 
 type Model struct {
-	tailStatus  *ipnstate.Status
-	nodeID      tsKey.NodePublic
-	keyMap      keymap.KeyMap
-	w, h        int
-	help        help.Model
-	actionsList actionlist.Model
-	helpH       int
-	detailH     int
-	contentH    int
-	messages    []string
-	pingCount   int
+	backend       ts.Backend
+	tailStatus    *ipnstate.Status
+	prefs         *ipn.Prefs
+	traffic       ts.TrafficDataMsg
+	nodeID        tsKey.NodePublic
+	keyMap        keymap.KeyMap
+	w, h          int
+	help          help.Model
+	actionsList   actionlist.Model
+	helpH         int
+	detailH       int
+	contentH      int
+	messages      []string
+	pingOpts      ts.PingOptions
+	ping          *pingRun
+	pingType      tailcfg.PingType
+	pingStats     *ts.PingStats
+	comparison    map[tailcfg.PingType]pingResultMsg
+	confirmLogout bool
+	picking       bool
+	picker        filepicker.Model
+	queue         []string
+	transfer      *ts.Transfer
+	waitingFiles  int
+	progress      progress.Model
 }
 
 type BackMsg bool
@@ -49,8 +67,78 @@ func (m Model) getCurrentNode() *ipnstate.PeerStatus {
 	return nil
 }
 
+func (m Model) exitNode() *ipnstate.PeerStatus {
+	for _, peer := range m.tailStatus.Peer {
+		if peer.ExitNode {
+			return peer
+		}
+	}
+	return nil
+}
+
+// exitNodeApproved reports whether the exit node routes advertised by this
+// device have been approved by the tailnet admin.
+func (m Model) exitNodeApproved() bool {
+	self := m.tailStatus.Self
+	return self.AllowedIPs != nil && tsaddr.ContainsExitRoutes(*self.AllowedIPs)
+}
+
+func (m Model) isPeer() bool {
+	return m.tailStatus != nil && m.tailStatus.Self.PublicKey != m.nodeID
+}
+
+func (m Model) actionItems() []actionlist.ActionListItem {
+	var actionItems []actionlist.ActionListItem
+	if m.tailStatus != nil {
+		if m.tailStatus.Self.PublicKey == m.nodeID {
+			connection := m.tailStatus.Self.Online
+			offerExitNode := "no"
+			if m.prefs.AdvertisesExitNode() {
+				offerExitNode = "yes"
+				if !m.exitNodeApproved() {
+					offerExitNode += " (awaiting approval)"
+				}
+			}
+			usingExitNode := "none"
+			if exitNode := m.exitNode(); exitNode != nil {
+				usingExitNode = exitNode.HostName
+			}
+			logoutDesc := "Log out of the current profile"
+			if m.confirmLogout {
+				logoutDesc = constants.WarningTextStyle.Render("Press enter again to log out")
+			}
+			tailnet := "none"
+			if m.tailStatus.CurrentTailnet != nil {
+				tailnet = m.tailStatus.CurrentTailnet.Name
+			}
+			actionItems = []actionlist.ActionListItem{
+				actionlist.NewActionListItem("> Tailscale", fmt.Sprintf("Connection: %t", connection), ts.ConnectAction),
+				actionlist.NewActionListItem("> Offer Exit Node", fmt.Sprintf("Offering: %s", offerExitNode), ts.OfferExitNode),
+				actionlist.NewActionListItem("> Exit Node", fmt.Sprintf("Using: %s", usingExitNode), ts.ExitNodesAction),
+				actionlist.NewActionListItem("> Profiles", fmt.Sprintf("Tailnet: %s", tailnet), ts.ProfilesAction),
+				actionlist.NewActionListItem("> Taildrop Inbox", fmt.Sprintf("Waiting files: %d", m.waitingFiles), ts.InboxAction),
+				actionlist.NewActionListItem("> Network Check", fmt.Sprintf("Home relay: %s", cmp.Or(m.tailStatus.Self.Relay, "none")), ts.NetcheckAction),
+				actionlist.NewActionListItem("> Preferences", "Hostname, shields up, DNS, SSH, tags...", ts.PrefsAction),
+				actionlist.NewActionListItem("> Subnet Routes", fmt.Sprintf("Advertising: %d, accept routes: %t", len(ts.SubnetRoutes(m.prefs)), m.prefs != nil && m.prefs.RouteAll), ts.RoutesAction),
+				actionlist.NewActionListItem("> Log out", logoutDesc, ts.LogoutAction),
+			}
+		} else {
+			actionItems = []actionlist.ActionListItem{
+				actionlist.NewActionListItem("> Ping", fmt.Sprintf("run tailscale ping, type: %s", pingModeName(m.pingType)), ts.PingAction),
+				actionlist.NewActionListItem("> Send file", "Send files with Taildrop", ts.SendFileAction),
+			}
+			if node := m.getCurrentNode(); node != nil && (node.ExitNodeOption || node.ExitNode) {
+				actionItems = append(actionItems, actionlist.NewActionListItem("> Use as exit node", fmt.Sprintf("In use: %t", node.ExitNode), ts.UseExitNodeAction))
+			}
+		}
+	}
+	return actionItems
+}
+
 func (m *Model) updateKeybindings() {
 	m.keyMap.Refresh.SetEnabled(false)
+	m.keyMap.Stop.SetEnabled(m.ping != nil)
+	m.keyMap.PingType.SetEnabled(m.ping == nil && m.isPeer() && m.actionsList.SelectedItem().Value() == ts.PingAction)
 	if m.help.ShowAll {
 		m.keyMap.ShowFullHelp.SetEnabled(false)
 		m.keyMap.CloseFullHelp.SetEnabled(true)
@@ -63,6 +151,27 @@ func (m *Model) updateKeybindings() {
 func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
+	if m.picking {
+		return m.filePickerKeyHandler(msg)
+	}
+	if m.transfer != nil {
+		if key.Matches(msg, m.keyMap.Cancel) {
+			m.transfer.Cancel()
+			m.queue = nil
+			return m, cmds
+		}
+		if key.Matches(msg, m.keyMap.Back) {
+			return m, append(cmds, types.NewStatusMsg(fmt.Sprintf("Transfer in progress, press %s to cancel.", m.keyMap.Cancel.Keys()[0])))
+		}
+	}
+	if m.ping != nil {
+		if key.Matches(msg, m.keyMap.Stop) || key.Matches(msg, m.keyMap.Cancel) {
+			return m, append(cmds, m.stopPing())
+		}
+		if key.Matches(msg, m.keyMap.Back) {
+			cmds = append(cmds, m.stopPing())
+		}
+	}
 	node := m.getCurrentNode()
 	if node != nil {
 		if key.Matches(msg, m.keyMap.CopyIpv4) || key.Matches(msg, m.keyMap.CopyIpv6) || key.Matches(msg, m.keyMap.CopyDNSName) {
@@ -83,12 +192,71 @@ func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 			}
 		}
 	}
+	confirmLogout := m.confirmLogout
+	m.confirmLogout = false
 	switch {
 	case key.Matches(msg, m.keyMap.Enter):
-		if m.actionsList.SelectedItem().Value() == ts.ConnectAction {
+		if m.actionsList.SelectedItem().Value() == ts.LogoutAction {
+			if confirmLogout {
+				cmd = func() tea.Msg {
+					return ts.LogoutMsg(true)
+				}
+			} else {
+				m.confirmLogout = true
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.ConnectAction {
 			cmd = func() tea.Msg {
 				return ts.ToggleConnectionMsg(true)
 			}
+		} else if m.actionsList.SelectedItem().Value() == ts.OfferExitNode {
+			offer := !m.prefs.AdvertisesExitNode()
+			cmd = func() tea.Msg {
+				return ts.OfferExitNodeMsg(offer)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.ProfilesAction {
+			cmd = func() tea.Msg {
+				return types.ShowProfilesMsg(true)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.InboxAction {
+			cmd = func() tea.Msg {
+				return types.ShowInboxMsg(true)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.NetcheckAction {
+			cmd = func() tea.Msg {
+				return types.ShowNetcheckMsg(true)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.PrefsAction {
+			cmd = func() tea.Msg {
+				return types.ShowPrefsMsg(true)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.RoutesAction {
+			cmd = func() tea.Msg {
+				return types.ShowRoutesMsg(true)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.ExitNodesAction {
+			cmd = func() tea.Msg {
+				return types.ShowExitNodesMsg(true)
+			}
+		} else if m.actionsList.SelectedItem().Value() == ts.UseExitNodeAction {
+			node := m.getCurrentNode()
+			if node != nil {
+				id := node.ID
+				if node.ExitNode {
+					id = ""
+				}
+				cmd = func() tea.Msg {
+					return ts.SetExitNodeMsg(id)
+				}
+			}
+		} else if m.transfer != nil && (m.actionsList.SelectedItem().Value() == ts.PingAction || m.actionsList.SelectedItem().Value() == ts.SendFileAction) {
+			cmd = types.NewStatusMsg(fmt.Sprintf("Transfer in progress, press %s to cancel.", m.keyMap.Cancel.Keys()[0]))
+		} else if m.ping != nil && (m.actionsList.SelectedItem().Value() == ts.PingAction || m.actionsList.SelectedItem().Value() == ts.SendFileAction) {
+			cmd = types.NewStatusMsg(fmt.Sprintf("Ping in progress, press %s to stop.", m.keyMap.Stop.Help().Key))
+		} else if m.actionsList.SelectedItem().Value() == ts.SendFileAction {
+			node := m.getCurrentNode()
+			if node != nil {
+				cmd = checkFileTarget(m.backend, node.ID)
+			}
 		} else if m.actionsList.SelectedItem().Value() == ts.PingAction {
 			node := m.getCurrentNode()
 			if node != nil {
@@ -97,6 +265,9 @@ func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 				}
 			}
 		}
+	case key.Matches(msg, m.keyMap.PingType):
+		m.cyclePingType()
+		cmd = m.actionsList.SetItems(m.actionItems())
 	case key.Matches(msg, m.keyMap.Back):
 		cmd = func() tea.Msg {
 			return BackMsg(true)
@@ -108,16 +279,23 @@ func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 	case key.Matches(msg, m.keyMap.CloseFullHelp):
 		m.help.ShowAll = false
 	}
+	if confirmLogout != m.confirmLogout {
+		cmds = append(cmds, m.actionsList.SetItems(m.actionItems()))
+	}
 
 	cmds = append(cmds, cmd)
 	return m, cmds
 }
 
 func (m Model) messagesView() string {
+	messages := strings.Join(m.messages, "\n")
+	if v := m.pingView(); v != "" {
+		messages += "\n\n" + v
+	}
 	v := lipgloss.JoinVertical(
 		lipgloss.Left,
 		constants.PrimaryTitleStyle.Render("Messages"),
-		constants.NormalTextStyle.Margin(1).Render(strings.Join(m.messages, "\n")))
+		constants.NormalTextStyle.Margin(1).Render(messages))
 	return lipgloss.NewStyle().Width(m.w / 2).Height(m.contentH).Render(v)
 }
 
@@ -125,9 +303,15 @@ func (m *Model) SetSize(w int, h int) {
 	m.w = w
 	m.h = h
 	m.helpH = lipgloss.Height(m.help.View(m.keyMap))
-	m.detailH = lipgloss.Height(NodeDetailRender(m.tailStatus, m.nodeID, ""))
+	m.detailH = lipgloss.Height(NodeDetailRender(m.tailStatus, m.prefs, m.traffic, m.nodeID, ""))
 	m.contentH = m.h - m.helpH - m.detailH
 	m.actionsList.SetSize(m.w/2, m.contentH)
+	m.picker.Height = max(m.contentH-pickerChromeH, 1)
+}
+
+// SetTraffic sets the traffic shown with the next status.
+func (m *Model) SetTraffic(traffic ts.TrafficDataMsg) {
+	m.traffic = traffic
 }
 
 func (m Model) Init() tea.Cmd {
@@ -138,100 +322,109 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
+	case ts.StatusDataMsg:
+		m.tailStatus = msg
+		cmds = append(cmds, m.actionsList.SetItems(m.actionItems()))
+		m.SetSize(m.w, m.h)
+	case ts.PrefsDataMsg:
+		m.prefs = msg
+		cmds = append(cmds, m.actionsList.SetItems(m.actionItems()))
+		m.SetSize(m.w, m.h)
 	case ts.PingMsg:
-		if m.pingCount <= 0 {
-			m.pingCount = 10
-			m.messages = []string{}
-			m.messages = append(m.messages, fmt.Sprintf("> Pinging %s. (max: 10 or until direct)", netip.Addr(msg)))
-			cmds = append(cmds, types.NewStatusMsg("Pinging..."))
-		}
-		m.pingCount -= 1
-		pr, err := ts.Ping(netip.Addr(msg))
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
-				m.messages = append(m.messages, constants.DimmedTextStyle.Render(fmt.Sprintf("ping %q timed out", netip.Addr(msg))))
-			} else {
-				m.messages = append(m.messages, constants.DimmedTextStyle.Render(fmt.Sprintf("error: %s", err)))
-			}
+		if m.ping == nil {
+			cmds = append(cmds, m.startPing(netip.Addr(msg)))
 		}
-		if pr != nil {
-			prmsg, err := ts.PingResultString(pr)
-			if err != nil {
-				m.messages = append(m.messages, err.Error())
-			} else {
-				m.messages = append(m.messages, constants.DimmedTextStyle.Render(prmsg))
-			}
-			if pr.Endpoint != "" {
-				m.pingCount = -1
-			}
+	case pingResultMsg:
+		m, cmd = m.handlePingResult(msg)
+		cmds = append(cmds, cmd)
+	case pingNextMsg:
+		m, cmd = m.handlePingNext(msg)
+		cmds = append(cmds, cmd)
+	case ts.WaitingFilesMsg:
+		m.waitingFiles = len(msg)
+		cmds = append(cmds, m.actionsList.SetItems(m.actionItems()))
+	case fileTargetMsg:
+		node := m.getCurrentNode()
+		if msg.err != nil {
+			m.messages = append(m.messages, constants.DangerTextStyle.Render(fmt.Sprintf("error: %s", msg.err)))
+		} else if !msg.ok && node != nil {
+			m.messages = append(m.messages, constants.WarningTextStyle.Render(fmt.Sprintf("Can't send files to %s. It must be online, yours and have Taildrop enabled.", node.HostName)))
+		} else if msg.ok {
+			m.picking = true
+			m.queue = nil
+			m.picker = newFilePicker(max(m.contentH-pickerChromeH, 1))
+			cmds = append(cmds, m.picker.Init())
 		}
-		if m.pingCount > 0 {
-			cmds = append(cmds, func() tea.Msg { return msg })
-		} else if m.pingCount == -1 {
-			m.messages = append(m.messages, "\nDone!")
-			cmds = append(cmds, types.NewStatusMsg("Pinging finished."))
-		} else {
-			m.messages = append(m.messages, "\nDone, direct connection not established!")
-			cmds = append(cmds, types.NewStatusMsg("Pinging finished, direct connectsion not established."))
+	case transferTickMsg:
+		if msg.t == m.transfer {
+			m.setLastMessage(m.transferProgress(msg.t))
+			cmds = append(cmds, transferTick(msg.t))
 		}
+	case transferDoneMsg:
+		m, cmd = m.handleTransferDone(msg)
+		cmds = append(cmds, cmd)
 	case tea.KeyMsg:
 		var kcmds []tea.Cmd
 		m, kcmds = m.keyBindingsHandler(msg)
 		cmds = append(cmds, kcmds...)
-		m.updateKeybindings()
 	default:
+		if m.picking {
+			m.picker, cmd = m.picker.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
-	maxMessageCount := m.contentH - 3
+	maxMessageCount := max(m.contentH-3, 0)
+	if v := m.pingView(); v != "" {
+		maxMessageCount = max(maxMessageCount-lipgloss.Height(v)-1, 0)
+	}
 	messageCount := len(m.messages)
 	if messageCount > maxMessageCount {
 		beg := messageCount - maxMessageCount
 		m.messages = m.messages[beg:]
 	}
-	m.actionsList, cmd = m.actionsList.Update(msg)
-	cmds = append(cmds, cmd)
+	if _, ok := msg.(tea.KeyMsg); !ok || !m.picking {
+		m.actionsList, cmd = m.actionsList.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	m.updateKeybindings()
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
+	actions := m.actionsList.View()
+	if m.picking {
+		actions = m.filePickerView()
+	}
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
-		NodeDetailRender(m.tailStatus, m.nodeID, ""),
-		lipgloss.JoinHorizontal(lipgloss.Top, m.actionsList.View(), m.messagesView()),
+		NodeDetailRender(m.tailStatus, m.prefs, m.traffic, m.nodeID, ""),
+		lipgloss.JoinHorizontal(lipgloss.Top, actions, m.messagesView()),
 		lipgloss.NewStyle().Margin(0, 2).Render(m.help.View(m.keyMap)),
 	)
 
 }
 
-func New(status *ipnstate.Status, nodeID tsKey.NodePublic, w, h int) Model {
+func New(backend ts.Backend, status *ipnstate.Status, prefs *ipn.Prefs, traffic ts.TrafficDataMsg, nodeID tsKey.NodePublic, pingOpts ts.PingOptions, w, h int) Model {
 	m := Model{
-		keyMap:     keymap.NewKeyMap(),
+		backend:    backend,
+		pingOpts:   pingOpts,
+		pingType:   tailcfg.PingDisco,
+		keyMap:     keymap.NewKeyMap(keymap.ViewDetails),
 		tailStatus: status,
+		prefs:      prefs,
+		traffic:    traffic,
 		nodeID:     nodeID,
 		w:          w,
 		h:          h,
 		help:       help.New(),
+		progress:   newProgress(),
 	}
 
-	var actionItems []actionlist.ActionListItem
-	if m.tailStatus != nil {
-		if m.tailStatus.Self.PublicKey == m.nodeID {
-			connection := m.tailStatus.Self.Online
-			// offerExitNode := m.tailStatus.Self.ExitNodeOption
-			actionItems = []actionlist.ActionListItem{
-				actionlist.NewActionListItem("> Tailscale", fmt.Sprintf("Connection: %t", connection), ts.ConnectAction),
-				// actionlist.NewActionListItem("> Offer Exit Node", fmt.Sprintf("%t", offerExitNode), ts.OfferExitNode),
-			}
-		} else {
-			actionItems = []actionlist.ActionListItem{
-				actionlist.NewActionListItem("> Ping", "run tailscale ping", ts.PingAction),
-			}
-		}
-	}
-
+	m.keyMap.Save.SetHelp(m.keyMap.Save.Help().Key, "send")
+	m.actionsList = actionlist.New(m.actionItems(), m.w/2, m.h)
 	m.updateKeybindings()
-	m.actionsList = actionlist.New(actionItems, m.w/2, m.h)
 	m.SetSize(m.w, m.h)
 	return m
 }
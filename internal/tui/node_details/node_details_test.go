@@ -0,0 +1,159 @@
+package nodedetails
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/tuitest"
+	tea "github.com/charmbracelet/bubbletea"
+	"tailscale.com/ipn/ipnstate"
+)
+
+// newHarness shows the details of the device named host, the messages of
+// the commands that the model does not handle itself are added to sent.
+func newHarness(t *testing.T, f *ts.FakeBackend, host string, sent *[]tea.Msg) *tuitest.Harness[Model] {
+	status, err := f.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefs, _ := f.GetPrefs(context.Background())
+	node := status.Self
+	for _, p := range status.Peer {
+		if p.HostName == host {
+			node = p
+		}
+	}
+	opts := ts.PingOptions{Count: 5, Timeout: time.Second}
+	m := New(f, status, prefs, nil, node.PublicKey, opts, 120, 40)
+	update := func(m Model, msg tea.Msg) (Model, tea.Cmd) {
+		switch msg.(type) {
+		case ts.LogoutMsg, ts.OfferExitNodeMsg, ts.ToggleConnectionMsg, ts.SetExitNodeMsg:
+			*sent = append(*sent, msg)
+		}
+		return m.Update(msg)
+	}
+	return tuitest.New(t, m, update)
+}
+
+// selectAction moves the cursor to action and presses enter.
+func selectAction(t *testing.T, h *tuitest.Harness[Model], action ts.ActionType) {
+	t.Helper()
+	for range len(h.Model.actionItems()) {
+		if h.Model.actionsList.SelectedItem().Value() == action {
+			h.Send(tuitest.Key("enter"))
+			return
+		}
+		h.Send(tuitest.Key("j"))
+	}
+	t.Fatalf("no %s action", action)
+}
+
+func hasMessage(m Model, s string) bool {
+	return slices.ContainsFunc(m.messages, func(msg string) bool { return strings.Contains(msg, s) })
+}
+
+func TestPing(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	f.QueuePing(netip.MustParseAddr("100.64.0.2"),
+		ts.FakePing{Result: &ipnstate.PingResult{NodeName: "server", NodeIP: "100.64.0.2", DERPRegionID: 1, DERPRegionCode: "nyc", LatencySeconds: 0.04}},
+		ts.FakePing{Result: &ipnstate.PingResult{NodeName: "server", NodeIP: "100.64.0.2", Endpoint: "192.0.2.1:41641", LatencySeconds: 0.01}},
+	)
+	var sent []tea.Msg
+	h := newHarness(t, f, "server", &sent)
+	selectAction(t, h, ts.PingAction)
+	h.Await("the ping to finish", func(m Model) bool { return hasMessage(m, "Done!") })
+	if m := h.Model; m.ping != nil || m.pingStats.Sent() != 2 {
+		t.Fatalf("ping running %t, %d sent, want stopped after 2", m.ping != nil, m.pingStats.Sent())
+	}
+	for _, want := range []string{"via DERP(nyc)", "via 192.0.2.1:41641", "2 sent, 2 received, 0% loss", "direct after"} {
+		if !hasMessage(h.Model, want) && !strings.Contains(h.Model.pingView(), want) {
+			t.Errorf("messages %q do not show %q", h.Model.messages, want)
+		}
+	}
+}
+
+func TestPingStop(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	f.QueuePing(netip.MustParseAddr("100.64.0.2"), ts.FakePing{Delay: time.Hour})
+	var sent []tea.Msg
+	h := newHarness(t, f, "server", &sent)
+	selectAction(t, h, ts.PingAction)
+	h.Await("the ping to start", func(m Model) bool { return m.ping != nil })
+	h.Send(tuitest.Key("s"))
+	if m := h.Model; m.ping != nil || !hasMessage(m, "Stopped.") {
+		t.Errorf("ping running %t, messages %q, want it stopped", m.ping != nil, m.messages)
+	}
+}
+
+func TestSendFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "notes.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	status := ts.FakeStatus()
+	f := ts.NewFakeBackend(status)
+	for _, p := range status.Peer {
+		if p.HostName == "server" {
+			f.SetFileTargets(p.ID)
+		}
+	}
+
+	var sent []tea.Msg
+	h := newHarness(t, f, "phone", &sent)
+	selectAction(t, h, ts.SendFileAction)
+	h.Await("the target check", func(m Model) bool { return hasMessage(m, "Can't send files to phone") })
+
+	h = newHarness(t, f, "server", &sent)
+	selectAction(t, h, ts.SendFileAction)
+	h.Await("the file picker", func(m Model) bool { return m.picking && strings.Contains(m.picker.View(), "notes.txt") })
+	for _, hint := range []string{"enter add/remove", "ctrl+s send", "esc/n cancel"} {
+		if !strings.Contains(h.Model.filePickerView(), hint) {
+			t.Errorf("file picker does not show the hint %q", hint)
+		}
+	}
+	h.Send(tuitest.Key("enter"))
+	if !slices.Equal(h.Model.queue, []string{filepath.Join(home, "notes.txt")}) {
+		t.Fatalf("queue %q, want notes.txt", h.Model.queue)
+	}
+	h.Send(tuitest.Key("ctrl+s"))
+	h.Await("the transfer to finish", func(m Model) bool { return m.transfer == nil && hasMessage(m, "Done!") })
+	if got := f.Pushed(); !slices.Equal(got, []string{"notes.txt"}) {
+		t.Errorf("pushed %q, want [notes.txt]", got)
+	}
+}
+
+func TestSelfActions(t *testing.T) {
+	f := ts.NewFakeBackend(ts.FakeStatus())
+	var sent []tea.Msg
+	h := newHarness(t, f, "laptop", &sent)
+
+	selectAction(t, h, ts.OfferExitNode)
+	h.Await("the exit node offer", func(Model) bool { return len(sent) == 1 })
+	if sent[0] != ts.OfferExitNodeMsg(true) {
+		t.Errorf("sent %v, want OfferExitNodeMsg(true)", sent[0])
+	}
+
+	selectAction(t, h, ts.LogoutAction)
+	if !h.Model.confirmLogout || !strings.Contains(h.Model.actionsList.SelectedItem().Description(), "Press enter again") {
+		t.Fatalf("logout not confirmed: %q", h.Model.actionsList.SelectedItem().Description())
+	}
+	h.Send(tuitest.Key("enter"))
+	h.Await("the logout", func(Model) bool { return len(sent) == 2 })
+	if sent[1] != ts.LogoutMsg(true) {
+		t.Errorf("sent %v, want LogoutMsg(true)", sent[1])
+	}
+
+	selectAction(t, h, ts.LogoutAction)
+	h.Send(tuitest.Key("k"))
+	if h.Model.confirmLogout {
+		t.Errorf("logout still awaiting confirmation after moving away")
+	}
+}
@@ -0,0 +1,268 @@
+package nodedetails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
+)
+
+// pingCompareAll is the choice of the ping type selector that sends one
+// ping of every type at once.
+const pingCompareAll tailcfg.PingType = "all"
+
+var pingModes = append(slices.Clone(ts.PingTypes), pingCompareAll)
+
+func pingModeName(t tailcfg.PingType) string {
+	if t == pingCompareAll {
+		return "compare all"
+	}
+	return string(t)
+}
+
+func pingLine(t tailcfg.PingType, line string) string {
+	return fmt.Sprintf("[%s] %s", t, line)
+}
+
+func (m *Model) cyclePingType() {
+	i := slices.Index(pingModes, m.pingType)
+	m.pingType = pingModes[(i+1)%len(pingModes)]
+}
+
+// pingRun is a series of pings to a single peer, stopped by cancel.
+type pingRun struct {
+	ip       netip.Addr
+	pingType tailcfg.PingType
+	ctx      context.Context
+	cancel   context.CancelFunc
+	sent     int
+}
+
+type pingResultMsg struct {
+	run      *pingRun
+	pingType tailcfg.PingType
+	pr       *ipnstate.PingResult
+	err      error
+}
+
+type pingNextMsg struct {
+	run *pingRun
+}
+
+func (m Model) sendPing(run *pingRun, pingType tailcfg.PingType) tea.Cmd {
+	run.sent++
+	b, timeout := m.backend, m.pingOpts.Timeout
+	return func() tea.Msg {
+		pr, err := ts.Ping(run.ctx, b, run.ip, pingType, timeout)
+		return pingResultMsg{run: run, pingType: pingType, pr: pr, err: err}
+	}
+}
+
+func (m *Model) startPing(ip netip.Addr) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.ping = &pingRun{ip: ip, pingType: m.pingType, ctx: ctx, cancel: cancel}
+	m.pingStats = nil
+	m.comparison = nil
+	if m.pingType == pingCompareAll {
+		m.comparison = map[tailcfg.PingType]pingResultMsg{}
+		m.messages = []string{fmt.Sprintf("> Comparing ping types to %s.", ip)}
+		cmds := []tea.Cmd{types.NewStatusMsg("Pinging...")}
+		for _, t := range ts.PingTypes {
+			cmds = append(cmds, m.sendPing(m.ping, t))
+		}
+		return tea.Batch(cmds...)
+	}
+	m.pingStats = ts.NewPingStats(m.pingType)
+	limit := "no limit"
+	if m.pingOpts.Count > 0 {
+		limit = "max: " + strconv.Itoa(m.pingOpts.Count)
+	}
+	until := "a reply"
+	if m.pingType == tailcfg.PingDisco {
+		until = "direct"
+	}
+	m.messages = []string{fmt.Sprintf("> Pinging %s with %s. (%s or until %s)", ip, m.pingType, limit, until)}
+	return tea.Batch(m.sendPing(m.ping, m.pingType), types.NewStatusMsg("Pinging..."))
+}
+
+// stopPing cancels the ping in flight, its result is ignored.
+func (m *Model) stopPing() tea.Cmd {
+	return m.finishPing("Stopped.", "Pinging stopped.")
+}
+
+func (m *Model) finishPing(message, status string) tea.Cmd {
+	m.ping.cancel()
+	m.ping = nil
+	m.messages = append(m.messages, "\n"+message)
+	if m.pingStats != nil {
+		m.messages = append(m.messages, m.pingStats.Summary()...)
+	}
+	return types.NewStatusMsg(status)
+}
+
+func (m Model) handlePingResult(msg pingResultMsg) (Model, tea.Cmd) {
+	run := msg.run
+	if run != m.ping {
+		return m, nil
+	}
+	if run.pingType == pingCompareAll {
+		m.comparison[msg.pingType] = msg
+		if len(m.comparison) < len(ts.PingTypes) {
+			return m, nil
+		}
+		return m, m.finishPing("Done!", "Ping comparison finished.")
+	}
+	m.pingStats.Add(msg.pr, msg.err)
+	if msg.err != nil {
+		if errors.Is(msg.err, context.DeadlineExceeded) {
+			m.messages = append(m.messages, constants.DimmedTextStyle.Render(pingLine(run.pingType, fmt.Sprintf("ping %q timed out", run.ip))))
+		} else {
+			m.messages = append(m.messages, constants.DimmedTextStyle.Render(pingLine(run.pingType, fmt.Sprintf("error: %s", msg.err))))
+		}
+	}
+	if pr := msg.pr; pr != nil {
+		prmsg, err := ts.PingResultString(pr, run.pingType)
+		if err != nil {
+			m.messages = append(m.messages, pingLine(run.pingType, err.Error()))
+		} else {
+			m.messages = append(m.messages, constants.DimmedTextStyle.Render(pingLine(run.pingType, prmsg)))
+			// Like `tailscale ping`, only disco pings go on until the path is
+			// direct, the others stop at the first reply.
+			if pr.Endpoint != "" || run.pingType != tailcfg.PingDisco {
+				return m, m.finishPing("Done!", "Pinging finished.")
+			}
+		}
+	}
+	if m.pingOpts.Count > 0 && run.sent >= m.pingOpts.Count {
+		if run.pingType != tailcfg.PingDisco {
+			return m, m.finishPing("Done, no reply!", "Pinging finished, no reply.")
+		}
+		return m, m.finishPing("Done, direct connection not established!", "Pinging finished, direct connection not established.")
+	}
+	// Like `tailscale ping`, only wait between replies, a timeout already
+	// took long enough.
+	if msg.err != nil || m.pingOpts.Interval <= 0 {
+		return m, m.sendPing(run, run.pingType)
+	}
+	return m, tea.Tick(m.pingOpts.Interval, func(time.Time) tea.Msg { return pingNextMsg{run: run} })
+}
+
+func (m Model) handlePingNext(msg pingNextMsg) (Model, tea.Cmd) {
+	if msg.run != m.ping {
+		return m, nil
+	}
+	return m, m.sendPing(msg.run, msg.run.pingType)
+}
+
+// pingView is drawn below the messages: the latency sparkline of a disco
+// ping or the results of a comparison.
+func (m Model) pingView() string {
+	switch {
+	case m.comparison != nil:
+		return m.comparisonView()
+	case m.pingStats != nil && m.pingStats.Type == tailcfg.PingDisco && m.pingStats.Sent() > 0:
+		return m.sparklineView()
+	}
+	return ""
+}
+
+// comparisonView lays the reply to each ping type side by side.
+func (m Model) comparisonView() string {
+	var rows [][]string
+	var styles []lipgloss.Style
+	for _, t := range ts.PingTypes {
+		result, latency, style := "waiting...", "-", constants.DimmedTextStyle
+		msg, ok := m.comparison[t]
+		switch {
+		case !ok && m.ping == nil:
+			result = "stopped"
+		case !ok:
+		case errors.Is(msg.err, context.DeadlineExceeded):
+			result = "timed out"
+		case msg.err != nil:
+			result, style = fmt.Sprintf("error: %s", msg.err), constants.DangerTextStyle
+		case msg.pr == nil:
+			result = "no reply"
+		case msg.pr.IsLocalIP:
+			result = "local ip"
+		case msg.pr.Err != "":
+			result, style = msg.pr.Err, constants.DangerTextStyle
+		default:
+			result, style = "pong", constants.SuccessTextStyle
+			if via := ts.PingVia(msg.pr, t); via != "" {
+				result += " via " + via
+			}
+			latency = time.Duration(msg.pr.LatencySeconds * float64(time.Second)).Round(time.Millisecond).String()
+		}
+		rows = append(rows, []string{string(t), result, latency})
+		styles = append(styles, style)
+	}
+	cellStyle := lipgloss.NewStyle().Padding(0, 1).MaxWidth(max(m.w/2-12, 10))
+	return table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(constants.MutedTextStyle).
+		BorderRow(false).
+		BorderColumn(false).
+		Headers("Type", "Result", "Latency").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return cellStyle.Inherit(constants.PrimaryTextStyle).Bold(true)
+			}
+			return cellStyle.Inherit(styles[row-1])
+		}).
+		Render()
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparklineView draws the latest latencies, relayed pongs in the warning
+// color and direct ones in the success color, and marks where the path
+// became direct.
+func (m Model) sparklineView() string {
+	width := max(m.w/2-2, 1)
+	samples := m.pingStats.Samples
+	offset := max(len(samples)-width, 0)
+	samples = samples[offset:]
+	var maxLatency time.Duration
+	for _, sample := range samples {
+		maxLatency = max(maxLatency, sample.Latency)
+	}
+	var spark strings.Builder
+	for _, sample := range samples {
+		if sample.Lost {
+			spark.WriteString(constants.DangerTextStyle.Render("×"))
+			continue
+		}
+		level := 0
+		if maxLatency > 0 {
+			level = int(float64(sample.Latency)/float64(maxLatency)*float64(len(sparkBars)-1) + 0.5)
+		}
+		style := constants.WarningTextStyle
+		if sample.Direct {
+			style = constants.SuccessTextStyle
+		}
+		spark.WriteString(style.Render(string(sparkBars[level])))
+	}
+	marker := constants.WarningTextStyle.Render("■ DERP") + " " + constants.SuccessTextStyle.Render("■ direct")
+	if i, after, ok := m.pingStats.DirectAfter(); ok {
+		marker = constants.SuccessTextStyle.Render(fmt.Sprintf("direct after %s", after.Round(100*time.Millisecond)))
+		if i >= offset {
+			marker = strings.Repeat(" ", i-offset) + constants.SuccessTextStyle.Render("^ ") + marker
+		}
+	}
+	return spark.String() + "\n" + marker
+}
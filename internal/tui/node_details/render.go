@@ -2,16 +2,21 @@ package nodedetails
 
 import (
 	"fmt"
+	"net/netip"
 	"strings"
 	"time"
 
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
 	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
 	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
 	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/tsaddr"
 	tsKey "tailscale.com/types/key"
+	"tailscale.com/types/views"
 )
 
-func NodeDetailRender(tsStatus *ipnstate.Status, nodeID tsKey.NodePublic, customTitle string) string {
+func NodeDetailRender(tsStatus *ipnstate.Status, prefs *ipn.Prefs, traffic ts.TrafficDataMsg, nodeID tsKey.NodePublic, customTitle string) string {
 	title := constants.PrimaryTitleStyle.Render("Node info")
 	if customTitle != "" {
 		title = customTitle
@@ -24,7 +29,11 @@ func NodeDetailRender(tsStatus *ipnstate.Status, nodeID tsKey.NodePublic, custom
 	offersExitNode := "no"
 	exitNode := constants.SecondaryTextStyle.Render("Exit node: ")
 	asExitNode := ""
+	routes := ""
+	allowedIPs := ""
 	keyExpiry := constants.SecondaryTextStyle.Render("Key expiry: ")
+	tags := ""
+	trafficInfo := ""
 	currentDevice := false
 	if tsStatus != nil {
 		node, ok := tsStatus.Peer[nodeID]
@@ -34,13 +43,24 @@ func NodeDetailRender(tsStatus *ipnstate.Status, nodeID tsKey.NodePublic, custom
 			ok = true
 		}
 		if ok {
-			if user, ok := tsStatus.User[node.UserID]; ok {
+			if ts.IsTagged(node) {
+				userInfo = "Tagged device"
+				tags = constants.SecondaryTextStyle.Render("Tags: ") + strings.Join(node.Tags.AsSlice(), ", ")
+			} else if user, ok := tsStatus.User[node.UserID]; ok {
 				userInfo = fmt.Sprintf("%s <%s>", user.DisplayName, user.LoginName)
 			} else {
 				userInfo = fmt.Sprintf("??? <%d>", node.UserID)
 			}
+			if currentDevice && tsStatus.CurrentTailnet != nil {
+				userInfo += constants.DimmedTextStyle.Render(" @ " + tsStatus.CurrentTailnet.Name)
+			}
 			if node.ExitNodeOption {
 				offersExitNode = constants.WarningTextStyle.Render("yes")
+			} else if currentDevice && prefs.AdvertisesExitNode() {
+				offersExitNode = constants.WarningTextStyle.Render("yes")
+				if node.AllowedIPs == nil || !tsaddr.ContainsExitRoutes(*node.AllowedIPs) {
+					offersExitNode += constants.DimmedTextStyle.Render(" (awaiting approval)")
+				}
 			}
 			var ipList []string
 			for _, ip := range node.TailscaleIPs {
@@ -68,6 +88,33 @@ func NodeDetailRender(tsStatus *ipnstate.Status, nodeID tsKey.NodePublic, custom
 				hostname += " " + constants.DimmedTextStyle.Render("*This device*")
 			}
 			relay += node.Relay
+			if !currentDevice {
+				t := traffic[node.PublicKey]
+				trafficInfo = constants.SecondaryTextStyle.Render("Traffic: ") +
+					fmt.Sprintf("rx %s %s", ts.FormatBytes(node.RxBytes), constants.DimmedTextStyle.Render("("+ts.FormatRate(t.RxRate)+")")) +
+					constants.DimmedTextStyle.Render(" / ") +
+					fmt.Sprintf("tx %s %s", ts.FormatBytes(node.TxBytes), constants.DimmedTextStyle.Render("("+ts.FormatRate(t.TxRate)+")"))
+			}
+			if currentDevice {
+				var routeList []string
+				for _, route := range ts.SubnetRoutes(prefs) {
+					if ts.RouteApproved(node.PrimaryRoutes, route) {
+						routeList = append(routeList, route.String())
+					} else {
+						routeList = append(routeList, constants.WarningTextStyle.Render(route.String()+" (not approved)"))
+					}
+				}
+				if len(routeList) > 0 {
+					routes = constants.SecondaryTextStyle.Render("Routes: ") + strings.Join(routeList, ", ")
+				}
+			} else {
+				if node.PrimaryRoutes != nil && node.PrimaryRoutes.Len() > 0 {
+					routes = constants.SecondaryTextStyle.Render("Routes: ") + joinPrefixes(*node.PrimaryRoutes)
+				}
+				if node.AllowedIPs != nil && node.AllowedIPs.Len() > 0 {
+					allowedIPs = constants.SecondaryTextStyle.Render("Allowed IPs: ") + joinPrefixes(*node.AllowedIPs)
+				}
+			}
 			exitNode += constants.DimmedTextStyle.Render("offers: ") + offersExitNode
 			if node.ExitNode {
 				asExitNode = constants.WarningTextStyle.Render("~ This node is currently being used as an exit node.")
@@ -76,12 +123,45 @@ func NodeDetailRender(tsStatus *ipnstate.Status, nodeID tsKey.NodePublic, custom
 				for _, peer := range tsStatus.Peer {
 					if peer.ID == tsStatus.ExitNodeStatus.ID {
 						exitNode += constants.DimmedTextStyle.Render(" / using: ") + constants.WarningTextStyle.Render(peer.HostName)
+						if !tsStatus.ExitNodeStatus.Online {
+							exitNode += " " + constants.DangerTextStyle.Render("(offline)")
+						}
 						break
 					}
 				}
+				if prefs != nil {
+					lanAccess := "blocked"
+					if prefs.ExitNodeAllowLANAccess {
+						lanAccess = "allowed"
+					}
+					exitNode += constants.DimmedTextStyle.Render(" / LAN access: ") + lanAccess
+				}
 			}
 		}
 	}
-	body := lipgloss.JoinVertical(lipgloss.Left, userInfo+"\n", hostname, status, ips, relay, keyExpiry, exitNode, asExitNode)
+	lines := []string{userInfo + "\n", hostname}
+	if tags != "" {
+		lines = append(lines, tags)
+	}
+	lines = append(lines, status, ips, relay)
+	if trafficInfo != "" {
+		lines = append(lines, trafficInfo)
+	}
+	if routes != "" {
+		lines = append(lines, routes)
+	}
+	if allowedIPs != "" {
+		lines = append(lines, allowedIPs)
+	}
+	lines = append(lines, keyExpiry, exitNode, asExitNode)
+	body := lipgloss.JoinVertical(lipgloss.Left, lines...)
 	return constants.HeaderStyle.Render(fmt.Sprintf("%s\n\n%s", title, body))
 }
+
+func joinPrefixes(prefixes views.Slice[netip.Prefix]) string {
+	var list []string
+	for i := range prefixes.Len() {
+		list = append(list, prefixes.At(i).String())
+	}
+	return strings.Join(list, ", ")
+}
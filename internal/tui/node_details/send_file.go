@@ -0,0 +1,192 @@
+package nodedetails
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/types"
+	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/tailcfg"
+)
+
+const transferTickInterval = 200 * time.Millisecond
+
+type fileTargetMsg struct {
+	ok  bool
+	err error
+}
+
+type transferTickMsg struct {
+	t *ts.Transfer
+}
+
+type transferDoneMsg struct {
+	t   *ts.Transfer
+	err error
+}
+
+func checkFileTarget(b ts.Backend, id tailcfg.StableNodeID) tea.Cmd {
+	return func() tea.Msg {
+		ok, err := ts.IsFileTarget(b, id)
+		return fileTargetMsg{ok: ok, err: err}
+	}
+}
+
+func sendFile(b ts.Backend, id tailcfg.StableNodeID, t *ts.Transfer) tea.Cmd {
+	return func() tea.Msg {
+		return transferDoneMsg{t: t, err: t.Send(b, id)}
+	}
+}
+
+func transferTick(t *ts.Transfer) tea.Cmd {
+	return tea.Tick(transferTickInterval, func(time.Time) tea.Msg {
+		return transferTickMsg{t: t}
+	})
+}
+
+func newFilePicker(h int) filepicker.Model {
+	fp := filepicker.New()
+	if home, err := os.UserHomeDir(); err == nil {
+		fp.CurrentDirectory = home
+	}
+	// esc closes the picker instead of going up a directory.
+	fp.KeyMap.Back = key.NewBinding(key.WithKeys("h", "backspace", "left"), key.WithHelp("h", "back"))
+	fp.KeyMap.Select.SetHelp(fp.KeyMap.Select.Help().Key, "add/remove")
+	fp.AutoHeight = false
+	fp.Height = h
+	fp.Styles.Cursor = fp.Styles.Cursor.Foreground(constants.ColorPrimary)
+	fp.Styles.Selected = fp.Styles.Selected.Foreground(constants.ColorPrimary)
+	return fp
+}
+
+// pickerChromeH is the number of lines around the file picker: title,
+// current directory, queue and hint.
+const pickerChromeH = 6
+
+func (m Model) filePickerKeyHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch {
+	case key.Matches(msg, m.keyMap.Save):
+		if len(m.queue) > 0 {
+			m.picking = false
+			cmds = append(cmds, m.startNextTransfer())
+		}
+	case key.Matches(msg, m.keyMap.Cancel):
+		m.picking = false
+		m.queue = nil
+	default:
+		m.picker, cmd = m.picker.Update(msg)
+		cmds = append(cmds, cmd)
+		if ok, path := m.picker.DidSelectFile(msg); ok {
+			if i := slices.Index(m.queue, path); i >= 0 {
+				m.queue = slices.Delete(m.queue, i, i+1)
+			} else {
+				m.queue = append(m.queue, path)
+			}
+		}
+	}
+	return m, cmds
+}
+
+// startNextTransfer starts sending the first queued file, skipping files
+// that can no longer be read.
+func (m *Model) startNextTransfer() tea.Cmd {
+	node := m.getCurrentNode()
+	for len(m.queue) > 0 {
+		path := m.queue[0]
+		m.queue = m.queue[1:]
+		t, err := ts.NewTransfer(path)
+		if err != nil {
+			m.messages = append(m.messages, constants.DangerTextStyle.Render(fmt.Sprintf("error: %s", err)))
+			continue
+		}
+		if node == nil {
+			t.Cancel()
+			break
+		}
+		m.transfer = t
+		m.messages = append(m.messages,
+			fmt.Sprintf("> Sending %s (%s) to %s", t.Name, ts.FormatBytes(t.Size), node.HostName),
+			m.transferProgress(t))
+		return tea.Batch(sendFile(m.backend, node.ID, t), transferTick(t), types.NewStatusMsg(fmt.Sprintf("Sending %s...", t.Name)))
+	}
+	m.queue = nil
+	return nil
+}
+
+func (m Model) transferProgress(t *ts.Transfer) string {
+	return fmt.Sprintf("%s %s / %s  %s/s",
+		m.progress.ViewAs(t.Progress()),
+		ts.FormatBytes(t.Sent()),
+		ts.FormatBytes(t.Size),
+		ts.FormatBytes(int64(t.Throughput())))
+}
+
+// setLastMessage replaces the progress line of the running transfer.
+func (m *Model) setLastMessage(s string) {
+	if len(m.messages) == 0 {
+		m.messages = append(m.messages, s)
+		return
+	}
+	m.messages[len(m.messages)-1] = s
+}
+
+func (m Model) handleTransferDone(msg transferDoneMsg) (Model, tea.Cmd) {
+	if msg.t != m.transfer {
+		return m, nil
+	}
+	m.transfer = nil
+	t := msg.t
+	elapsed := time.Since(t.Started).Round(100 * time.Millisecond)
+	switch {
+	case msg.err == context.Canceled:
+		m.setLastMessage(constants.WarningTextStyle.Render(fmt.Sprintf("Cancelled %s after %s", t.Name, ts.FormatBytes(t.Sent()))))
+		return m, types.NewStatusMsg("Transfer cancelled.")
+	case msg.err != nil:
+		m.setLastMessage(constants.DangerTextStyle.Render(fmt.Sprintf("error: %s", msg.err)))
+	default:
+		m.setLastMessage(constants.DimmedTextStyle.Render(fmt.Sprintf("Sent %s in %s (%s/s)", t.Name, elapsed, ts.FormatBytes(int64(t.Throughput())))))
+	}
+	if len(m.queue) > 0 {
+		return m, m.startNextTransfer()
+	}
+	m.messages = append(m.messages, "\nDone!")
+	return m, types.NewStatusMsg("Sending finished.")
+}
+
+func (m Model) filePickerView() string {
+	var queued []string
+	for _, path := range m.queue {
+		queued = append(queued, filepath.Base(path))
+	}
+	queue := "Queue: empty"
+	if len(queued) > 0 {
+		queue = fmt.Sprintf("Queue (%d): %s", len(queued), strings.Join(queued, ", "))
+	}
+	w := max(m.w/2-4, 0)
+	v := lipgloss.JoinVertical(
+		lipgloss.Left,
+		constants.PrimaryTitleStyle.Render("Send file"),
+		constants.DimmedTextStyle.MaxWidth(w).Render(m.picker.CurrentDirectory),
+		m.picker.View(),
+		constants.NormalTextStyle.MaxWidth(w).Render(queue),
+		lipgloss.NewStyle().MaxWidth(w).Render(m.help.ShortHelpView([]key.Binding{m.picker.KeyMap.Select, m.keyMap.Save, m.keyMap.Cancel})),
+	)
+	return lipgloss.NewStyle().PaddingLeft(2).Width(m.w / 2).Height(m.contentH).MaxHeight(m.contentH).Render(v)
+}
+
+func newProgress() progress.Model {
+	return progress.New(progress.WithSolidFill(constants.Resolve(constants.ColorPrimary)), progress.WithWidth(24))
+}
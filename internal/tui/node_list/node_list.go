@@ -2,7 +2,9 @@ package nodelist
 
 import (
 	"fmt"
-	"sort"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/atotto/clipboard"
@@ -13,6 +15,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"tailscale.com/ipn/ipnstate"
@@ -22,6 +25,7 @@ import (
 type listItem struct {
 	title, desc string
 	status      *ipnstate.PeerStatus
+	group       string
 }
 
 func (i listItem) Title() string                { return i.title }
@@ -29,34 +33,81 @@ func (i listItem) Description() string          { return i.desc }
 func (i listItem) Status() *ipnstate.PeerStatus { return i.status }
 func (i listItem) FilterValue() string          { return i.title + " " + i.desc }
 
+// groupItem is the header of a group of nodes, it is left out of filtering.
+type groupItem struct {
+	name          string
+	online, total int
+	collapsed     bool
+}
+
+func (i groupItem) Title() string {
+	arrow := "▾"
+	if i.collapsed {
+		arrow = "▸"
+	}
+	return constants.SecondaryTextStyle.Bold(true).Render(arrow + " " + i.name)
+}
+func (i groupItem) Description() string { return fmt.Sprintf("%d/%d online", i.online, i.total) }
+func (i groupItem) FilterValue() string { return "" }
+
 type Model struct {
-	tailStatus *ipnstate.Status
-	exitNode   string
-	list       list.Model
-	keyMap     keymap.KeyMap
-	w          int
-	h          int
+	tailStatus   *ipnstate.Status
+	latencies    map[tsKey.NodePublic]ts.PeerLatency
+	traffic      ts.TrafficDataMsg
+	order        ts.PeerOrder
+	grouping     ts.PeerGrouping
+	collapsed    map[string]bool
+	filters      []ts.SavedFilter
+	filterIdx    int
+	filterErr    string
+	reselect     string
+	tags         []string
+	exitNode     string
+	list         list.Model
+	exporting    bool
+	exportFormat ts.ExportFormat
+	naming       bool
+	query        string
+	input        textinput.Model
+	inputErr     string
+	keyMap       keymap.KeyMap
+	w            int
+	h            int
 }
 
+const inputH = 3
+
 func (m *Model) SetSize(w int, h int) {
 	m.w = w
 	m.h = h
+	if m.prompting() {
+		h -= inputH
+	}
+	if m.filterErr != "" {
+		h--
+	}
 	m.list.SetSize(w, h)
 }
 
 type NodeSelectedMsg tsKey.NodePublic
 
+// FilterSavedMsg is a query saved with a name, kept for the session.
+type FilterSavedMsg ts.SavedFilter
+
 func (m *Model) updateKeybindings() {
-	if m.list.SelectedItem() != nil {
-		m.keyMap.CopyIpv4.SetEnabled(true)
-		m.keyMap.CopyIpv6.SetEnabled(true)
-		m.keyMap.CopyDNSName.SetEnabled(true)
-		m.keyMap.Enter.SetEnabled(true)
+	_, isPeer := m.selectedPeer()
+	hasItem := m.list.SelectedItem() != nil
+	m.keyMap.CopyIpv4.SetEnabled(isPeer)
+	m.keyMap.CopyIpv6.SetEnabled(isPeer)
+	m.keyMap.CopyDNSName.SetEnabled(isPeer)
+	m.keyMap.Enter.SetEnabled(hasItem)
+	m.keyMap.Export.SetEnabled(hasItem)
+	m.keyMap.SaveFilter.SetEnabled(m.list.FilterState() == list.FilterApplied)
+	m.keyMap.Collapse.SetEnabled(hasItem && m.grouping != ts.GroupNone && m.list.FilterState() == list.Unfiltered)
+	if isPeer {
+		m.keyMap.Enter.SetHelp(m.keyMap.Enter.Help().Key, "details")
 	} else {
-		m.keyMap.CopyIpv4.SetEnabled(false)
-		m.keyMap.CopyIpv6.SetEnabled(false)
-		m.keyMap.CopyDNSName.SetEnabled(false)
-		m.keyMap.Enter.SetEnabled(false)
+		m.keyMap.Enter.SetHelp(m.keyMap.Enter.Help().Key, "collapse group")
 	}
 	m.keyMap.Back.SetEnabled(false)
 	m.keyMap.Quit.SetEnabled(false)
@@ -67,18 +118,46 @@ func (m *Model) updateKeybindings() {
 	m.list.KeyMap.PrevPage.SetEnabled(false)
 }
 
+// selectedPeer returns the selected node, it is false on a group header.
+func (m Model) selectedPeer() (listItem, bool) {
+	item, ok := m.list.SelectedItem().(listItem)
+	return item, ok
+}
+
+// toggleGroup collapses or expands the group of the selected item and
+// selects its header.
+func (m *Model) toggleGroup() tea.Cmd {
+	var name string
+	switch item := m.list.SelectedItem().(type) {
+	case groupItem:
+		name = item.name
+	case listItem:
+		name = item.group
+	}
+	m.collapsed[name] = !m.collapsed[name]
+	cmd := m.setItems()
+	for i, item := range m.list.Items() {
+		if g, ok := item.(groupItem); ok && g.name == name {
+			m.list.Select(i)
+			break
+		}
+	}
+	return cmd
+}
+
 func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	if key.Matches(msg, m.keyMap.CopyIpv4) || key.Matches(msg, m.keyMap.CopyIpv6) || key.Matches(msg, m.keyMap.CopyDNSName) {
 		copyStr := ""
-		ipCount := len(m.list.SelectedItem().(listItem).status.TailscaleIPs)
+		peer, _ := m.selectedPeer()
+		ipCount := len(peer.status.TailscaleIPs)
 		if ipCount > 0 && key.Matches(msg, m.keyMap.CopyIpv4) {
-			copyStr = m.list.SelectedItem().(listItem).status.TailscaleIPs[0].String()
+			copyStr = peer.status.TailscaleIPs[0].String()
 		} else if ipCount > 1 && key.Matches(msg, m.keyMap.CopyIpv6) {
-			copyStr = m.list.SelectedItem().(listItem).status.TailscaleIPs[1].String()
+			copyStr = peer.status.TailscaleIPs[1].String()
 		} else if key.Matches(msg, m.keyMap.CopyDNSName) {
-			copyStr = m.list.SelectedItem().(listItem).status.DNSName
+			copyStr = peer.status.DNSName
 		}
 		if copyStr == "" {
 			m.list.NewStatusMessage("Sorry, nothing to copy.")
@@ -99,8 +178,79 @@ func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 		cmd = func() tea.Msg { return types.RefreshMsg(true) }
 		cmds = append(cmds, cmd)
 	}
+	if key.Matches(msg, m.keyMap.ExitNodes) {
+		cmd = func() tea.Msg { return types.ShowExitNodesMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.Prefs) {
+		cmd = func() tea.Msg { return types.ShowPrefsMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.Profiles) {
+		cmd = func() tea.Msg { return types.ShowProfilesMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.Inbox) {
+		cmd = func() tea.Msg { return types.ShowInboxMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.Netcheck) {
+		cmd = func() tea.Msg { return types.ShowNetcheckMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.Probe) {
+		cmd = func() tea.Msg { return ts.ToggleProbeMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.Sort) || key.Matches(msg, m.keyMap.ReverseSort) {
+		if key.Matches(msg, m.keyMap.Sort) {
+			m.order.By = (m.order.By + 1) % (ts.SortTopTalkers + 1)
+		} else {
+			m.order.Descending = !m.order.Descending
+		}
+		m.updateTitle()
+		cmds = append(cmds, m.setItems())
+		if m.order.By == ts.SortLatency && m.latencies == nil {
+			m.list.NewStatusMessage(fmt.Sprintf("Latency probing is off, press %s to start it.", m.keyMap.Probe.Help().Key))
+		}
+	}
+	if key.Matches(msg, m.keyMap.Group) {
+		m.grouping = (m.grouping + 1) % (ts.GroupSharing + 1)
+		m.collapsed = map[string]bool{}
+		m.updateTitle()
+		cmds = append(cmds, m.setItems())
+	}
+	if key.Matches(msg, m.keyMap.Collapse) {
+		cmds = append(cmds, m.toggleGroup())
+	}
+	if key.Matches(msg, m.keyMap.Tags) {
+		cmd = func() tea.Msg { return types.ShowTagsMsg(true) }
+		cmds = append(cmds, cmd)
+	}
+	if key.Matches(msg, m.keyMap.SavedFilter) {
+		if len(m.filters) == 0 {
+			m.list.NewStatusMessage(fmt.Sprintf("No saved filters, save the current one with %s or add them under [filters] in the config file.", m.keyMap.SaveFilter.Help().Key))
+		} else {
+			m.filterIdx++
+			if m.filterIdx == len(m.filters) {
+				m.filterIdx = -1
+			}
+			m.updateTitle()
+			cmds = append(cmds, m.setItems())
+		}
+	}
+	if key.Matches(msg, m.keyMap.SaveFilter) {
+		cmds = append(cmds, m.startNaming())
+	}
+	if key.Matches(msg, m.keyMap.Export) {
+		cmds = append(cmds, m.startExporting())
+	}
 	if key.Matches(msg, m.keyMap.Enter) {
-		cmd = func() tea.Msg { return NodeSelectedMsg(m.list.SelectedItem().(listItem).status.PublicKey) }
+		if peer, ok := m.selectedPeer(); ok {
+			cmd = func() tea.Msg { return NodeSelectedMsg(peer.status.PublicKey) }
+		} else {
+			cmd = m.toggleGroup()
+		}
 		cmds = append(cmds, cmd)
 	}
 	return m, cmds
@@ -108,61 +258,378 @@ func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
 
 func (m *Model) getItems() []list.Item {
 	items := []list.Item{}
-	peers := []*ipnstate.PeerStatus{}
-
 	if m.tailStatus == nil {
 		return items
 	}
 
+	m.exitNode = ""
 	for _, v := range m.tailStatus.Peer {
-		peers = append(peers, v)
-	}
-	sort.Slice(peers, func(i, j int) bool {
-		ownNode1 := m.tailStatus.Self.UserID == peers[i].UserID
-		ownNode2 := m.tailStatus.Self.UserID == peers[j].UserID
-		first := fmt.Sprint(!ownNode1) + strings.ToLower(peers[i].HostName)
-		second := fmt.Sprint(!ownNode2) + strings.ToLower(peers[j].HostName)
-		return first < second
+		if v.ExitNode {
+			m.exitNode = v.PublicKey.String()
+		}
+	}
+	peers := m.peers()
+	if m.grouping == ts.GroupNone {
+		for _, v := range peers {
+			items = append(items, m.peerItem(v, ""))
+		}
+		return items
+	}
+	for _, g := range ts.GroupPeers(m.tailStatus, peers, m.grouping) {
+		collapsed := m.collapsed[g.Name]
+		items = append(items, groupItem{name: g.Name, online: g.Online, total: len(g.Peers), collapsed: collapsed})
+		// Filters look through collapsed groups too.
+		if collapsed && m.list.FilterState() == list.Unfiltered {
+			continue
+		}
+		for _, v := range g.Peers {
+			items = append(items, m.peerItem(v, g.Name))
+		}
+	}
+	return items
+}
+
+// setItems updates the items and the filter matching them.
+// setItems rebuilds the items and keeps the same node or group selected,
+// once the filter has run again when filtering.
+func (m *Model) setItems() tea.Cmd {
+	id := itemID(m.list.SelectedItem())
+	items := m.getItems()
+	m.list.Filter = m.queryFilter(items)
+	cmd := m.list.SetItems(items)
+	if m.list.FilterState() == list.Unfiltered {
+		m.selectItem(id)
+	} else {
+		m.reselect = id
+	}
+	return cmd
+}
+
+// itemID identifies a node by its key and a group header by its name.
+func itemID(item list.Item) string {
+	switch item := item.(type) {
+	case listItem:
+		return item.status.PublicKey.String()
+	case groupItem:
+		return "group:" + item.name
+	}
+	return ""
+}
+
+func (m *Model) selectItem(id string) {
+	if id == "" {
+		return
+	}
+	for i, item := range m.list.VisibleItems() {
+		if itemID(item) == id {
+			m.list.Select(i)
+			return
+		}
+	}
+}
+
+// queryFilter filters items with a query, the free text is matched like the
+// default filter does. Queries that do not parse are matched as free text.
+func (m Model) queryFilter(items []list.Item) list.FilterFunc {
+	status := m.tailStatus
+	return func(term string, targets []string) []list.Rank {
+		f, err := ts.ParsePeerFilter(term)
+		if err != nil {
+			return list.DefaultFilter(term, targets)
+		}
+		var ranks []list.Rank
+		if f.Text() == "" {
+			for i := range targets {
+				ranks = append(ranks, list.Rank{Index: i})
+			}
+		} else {
+			ranks = list.DefaultFilter(f.Text(), targets)
+		}
+		return slices.DeleteFunc(ranks, func(r list.Rank) bool {
+			item, ok := items[r.Index].(listItem)
+			return !ok || !f.MatchFields(status, item.status)
+		})
+	}
+}
+
+// peers returns the sorted peers with one of the tags picked and matching
+// the saved filter in use.
+func (m Model) peers() []*ipnstate.PeerStatus {
+	peers := ts.SortedPeers(m.tailStatus)
+	ts.SortPeers(m.tailStatus, peers, m.order, m.latencies, m.traffic)
+	if len(m.tags) > 0 {
+		peers = slices.DeleteFunc(peers, func(p *ipnstate.PeerStatus) bool {
+			return !ts.HasAnyTag(p, m.tags)
+		})
+	}
+	if m.filterIdx < 0 {
+		return peers
+	}
+	f, _ := ts.ParsePeerFilter(m.filters[m.filterIdx].Query)
+	return slices.DeleteFunc(peers, func(p *ipnstate.PeerStatus) bool {
+		return !f.Match(m.tailStatus, p)
 	})
+}
 
-	peers = append([]*ipnstate.PeerStatus{m.tailStatus.Self}, peers...)
+func (m Model) peerItem(v *ipnstate.PeerStatus, group string) listItem {
+	state := constants.DangerTextStyle.Render("●")
+	if v.Online {
+		state = constants.SuccessTextStyle.Render("●")
+	}
+	hostName := v.HostName
+	owner := "my device"
+	if v.ID == m.tailStatus.Self.ID {
+		hostName = "◆ " + hostName
+		owner = "this device"
+	}
+	if ts.IsTagged(v) && v.ID != m.tailStatus.Self.ID {
+		owner = "tagged device"
+	} else if v.UserID != m.tailStatus.Self.UserID {
+		owner = "from:" + m.tailStatus.User[v.UserID].LoginName
+	}
+	owner = constants.DimmedTextStyle.Render("[" + owner + "]")
+	exitNode := ""
+	if v.ExitNodeOption {
+		exitNode = constants.DimmedTextStyle.Bold(true).Render("[→]")
+	}
+	if v.ExitNode {
+		exitNode = constants.SuccessTextStyle.Bold(true).Render("[→]")
+	}
+	os := constants.NormalTextStyle.Render(v.OS)
+	if ts.IsTagged(v) {
+		os += " " + constants.SecondaryTextStyle.Render(strings.Join(v.Tags.AsSlice(), " "))
+	}
+	title := fmt.Sprintf("%s %s %s %s %s", hostName, state, owner, os, exitNode)
+	desc := "- "
+	var ips []string
+	for _, ip := range v.TailscaleIPs {
+		ips = append(ips, ip.String())
+	}
+	ips = append(ips, v.DNSName)
+	desc += strings.Join(ips, " | ")
+	if l, ok := m.latencies[v.PublicKey]; ok {
+		desc += " " + latencyStyle(l).Render("· "+l.String())
+	}
+	if t := m.traffic[v.PublicKey]; t.Rate() > 0 {
+		desc += " " + constants.SecondaryTextStyle.Render(fmt.Sprintf("· ↓%s ↑%s", ts.FormatRate(t.RxRate), ts.FormatRate(t.TxRate)))
+	}
+	return listItem{title: title, desc: desc, status: v, group: group}
+}
 
-	m.exitNode = ""
-	for _, v := range peers {
-		state := constants.DangerTextStyle.Render("●")
-		if v.Online {
-			state = constants.SuccessTextStyle.Render("●")
+func (m *Model) startExporting() tea.Cmd {
+	m.exporting = true
+	m.inputErr = ""
+	m.input.SetValue("")
+	m.updatePrompt()
+	m.SetSize(m.w, m.h)
+	return m.input.Focus()
+}
+
+func (m *Model) stopExporting() {
+	m.exporting = false
+	m.input.Blur()
+	m.SetSize(m.w, m.h)
+}
+
+func (m Model) prompting() bool {
+	return m.exporting || m.naming
+}
+
+// startNaming asks for the name to save the query of the filter under.
+func (m *Model) startNaming() tea.Cmd {
+	m.query = strings.TrimSpace(m.list.FilterValue())
+	if _, err := ts.ParsePeerFilter(m.query); err != nil {
+		m.list.NewStatusMessage(fmt.Sprintf("Can't save the filter: %s", err))
+		return nil
+	}
+	m.naming = true
+	m.inputErr = ""
+	m.input.SetValue("")
+	m.input.Prompt = fmt.Sprintf("Save %q as: ", m.query)
+	m.SetSize(m.w, m.h)
+	return m.input.Focus()
+}
+
+func (m *Model) stopNaming() {
+	m.naming = false
+	m.input.Blur()
+	m.updatePrompt()
+	m.SetSize(m.w, m.h)
+}
+
+// saveFilter adds the query as a saved filter and applies it in place of
+// the text filter.
+func (m *Model) saveFilter(name string) tea.Cmd {
+	f := ts.SavedFilter{Name: name, Query: m.query}
+	m.filters = append(slices.Clone(m.filters), f)
+	slices.SortFunc(m.filters, func(a, b ts.SavedFilter) int { return strings.Compare(a.Name, b.Name) })
+	m.filterIdx = slices.Index(m.filters, f)
+	m.list.ResetFilter()
+	m.updateTitle()
+	return tea.Batch(m.setItems(), func() tea.Msg { return FilterSavedMsg(f) })
+}
+
+func (m *Model) updatePrompt() {
+	m.input.Prompt = fmt.Sprintf("Export %s to: ", m.exportFormat)
+}
+
+// visiblePeers returns the peers matching the filter, all of them when
+// there is none, including the ones in collapsed groups.
+func (m Model) visiblePeers() []*ipnstate.PeerStatus {
+	if m.list.FilterState() == list.Unfiltered {
+		return m.peers()
+	}
+	var peers []*ipnstate.PeerStatus
+	seen := map[*ipnstate.PeerStatus]bool{}
+	for _, item := range m.list.VisibleItems() {
+		if item, ok := item.(listItem); ok && !seen[item.status] {
+			seen[item.status] = true
+			peers = append(peers, item.status)
 		}
-		hostName := v.HostName
-		owner := "my device"
-		if v.ID == m.tailStatus.Self.ID {
-			hostName = "◆ " + hostName
-			owner = "this device"
+	}
+	return peers
+}
+
+func exportPeers(status *ipnstate.Status, peers []*ipnstate.PeerStatus, format ts.ExportFormat, path string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := ts.ExportPeers(status, peers, format)
+		if err == nil {
+			if path == "" {
+				err = clipboard.WriteAll(string(data))
+			} else {
+				err = os.WriteFile(path, data, 0o644)
+			}
 		}
-		if v.UserID != m.tailStatus.Self.UserID {
-			owner = "from:" + m.tailStatus.User[v.UserID].LoginName
+		if err != nil {
+			return types.StatusMsg(fmt.Sprintf("Sorry, error occured: %s", err))
 		}
-		owner = constants.DimmedTextStyle.Render("[" + owner + "]")
-		exitNode := ""
-		if v.ExitNodeOption {
-			exitNode = constants.DimmedTextStyle.Bold(true).Render("[→]")
+		if path == "" {
+			path = "clipboard"
 		}
-		if v.ExitNode {
-			m.exitNode = v.PublicKey.String()
-			exitNode = constants.SuccessTextStyle.Bold(true).Render("[→]")
+		return types.StatusMsg(fmt.Sprintf("Exported %d devices as %s to %s.", len(peers), format, path))
+	}
+}
+
+func (m Model) nameHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.stopNaming()
+	case tea.KeyEnter:
+		name := strings.TrimSpace(m.input.Value())
+		if name == "" {
+			m.inputErr = "the name is empty"
+			break
 		}
-		os := constants.NormalTextStyle.Render(v.OS)
-		title := fmt.Sprintf("%s %s %s %s %s", hostName, state, owner, os, exitNode)
-		desc := "- "
-		var ips []string
-		for _, ip := range v.TailscaleIPs {
-			ips = append(ips, ip.String())
+		if slices.ContainsFunc(m.filters, func(f ts.SavedFilter) bool { return f.Name == name }) {
+			m.inputErr = fmt.Sprintf("a filter named %q already exists", name)
+			break
 		}
-		ips = append(ips, v.DNSName)
-		desc += strings.Join(ips, " | ")
-		items = append(items, listItem{title: title, desc: desc, status: v})
+		m.stopNaming()
+		cmds = append(cmds, m.saveFilter(name))
+		m.list.NewStatusMessage(fmt.Sprintf("Saved the filter %q for this session, add it under [filters] in the config file to keep it.", name))
+	default:
+		m.inputErr = ""
+		m.input, cmd = m.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, cmds
+}
+
+func (m Model) inputHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	if m.naming {
+		return m.nameHandler(msg)
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.stopExporting()
+	case tea.KeyTab:
+		m.exportFormat = (m.exportFormat + 1) % (ts.ExportJSON + 1)
+		m.updatePrompt()
+		if path := m.input.Value(); path != "" {
+			if _, ok := ts.ExportFormatOf(path); ok {
+				m.input.SetValue(strings.TrimSuffix(path, filepath.Ext(path)) + m.exportFormat.Ext())
+			}
+		}
+	case tea.KeyEnter:
+		path := ts.ExpandHome(m.input.Value())
+		format := m.exportFormat
+		if path != "" {
+			if f, ok := ts.ExportFormatOf(path); ok {
+				format = f
+			} else {
+				path += format.Ext()
+			}
+			if _, err := os.Stat(path); err == nil {
+				m.inputErr = fmt.Sprintf("%s already exists", path)
+				break
+			}
+		}
+		m.stopExporting()
+		cmds = append(cmds, exportPeers(m.tailStatus, m.visiblePeers(), format, path))
+	default:
+		m.inputErr = ""
+		m.input, cmd = m.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, cmds
+}
+
+func latencyStyle(l ts.PeerLatency) lipgloss.Style {
+	switch {
+	case l.Lost:
+		return constants.DangerTextStyle
+	case l.Direct:
+		return constants.SuccessTextStyle
+	}
+	return constants.WarningTextStyle
+}
+
+func (m *Model) updateTitle() {
+	m.list.Title = "Nodes"
+	if m.order != (ts.PeerOrder{}) {
+		m.list.Title += " · " + m.order.String()
+	}
+	if m.grouping != ts.GroupNone {
+		m.list.Title += " · grouped by " + m.grouping.String()
+	}
+	if m.filterIdx >= 0 {
+		m.list.Title += " · " + m.filters[m.filterIdx].Name
+	}
+	if len(m.tags) > 0 {
+		m.list.Title += " · " + strings.Join(m.tags, ", ")
 	}
-	return items
+}
+
+// SetTraffic sets the traffic shown with the next status.
+func (m *Model) SetTraffic(traffic ts.TrafficDataMsg) {
+	m.traffic = traffic
+}
+
+// Tags returns the tags picked to filter the list.
+func (m Model) Tags() []string {
+	return m.tags
+}
+
+// SetTags shows only the devices with one of tags, all of them when there
+// are none.
+func (m *Model) SetTags(tags []string) tea.Cmd {
+	m.tags = tags
+	m.updateTitle()
+	return m.setItems()
+}
+
+// Order returns the order chosen with the sort keys.
+func (m Model) Order() ts.PeerOrder {
+	return m.order
+}
+
+// Grouping returns the grouping chosen with the group key.
+func (m Model) Grouping() ts.PeerGrouping {
+	return m.grouping
 }
 
 func (m Model) Init() tea.Cmd {
@@ -176,27 +643,81 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case ts.StatusDataMsg:
 		m.tailStatus = msg
 		if m.tailStatus != nil {
-			cmds = append(cmds, m.list.SetItems(m.getItems()))
+			cmds = append(cmds, m.setItems())
 		}
 		m.list.StopSpinner()
+	case ts.ProbeDataMsg:
+		m.latencies = msg
+		cmds = append(cmds, m.setItems())
 	case tea.KeyMsg:
+		if m.prompting() {
+			var kcmds []tea.Cmd
+			m, kcmds = m.inputHandler(msg)
+			return m, tea.Batch(kcmds...)
+		}
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
 		var kcmds []tea.Cmd
 		m, kcmds = m.keyBindingsHandler(msg)
 		cmds = append(cmds, kcmds...)
 	default:
+		if m.prompting() {
+			m.input, cmd = m.input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
+	wasFiltered := m.list.FilterState() != list.Unfiltered
 	m.list, cmd = m.list.Update(msg)
 	cmds = append(cmds, cmd)
+	if _, ok := msg.(list.FilterMatchesMsg); ok && m.reselect != "" {
+		m.selectItem(m.reselect)
+		m.reselect = ""
+	}
+	if filtered := m.list.FilterState() != list.Unfiltered; filtered != wasFiltered {
+		cmds = append(cmds, m.setItems())
+	}
+	m.updateFilterErr()
 	m.updateKeybindings()
 	return m, tea.Batch(cmds...)
 }
 
+// updateFilterErr shows why the query being typed does not parse.
+func (m *Model) updateFilterErr() {
+	filterErr := ""
+	if m.list.FilterState() == list.Filtering {
+		if _, err := ts.ParsePeerFilter(m.list.FilterInput.Value()); err != nil {
+			filterErr = err.Error()
+		}
+	}
+	if filterErr != m.filterErr {
+		m.filterErr = filterErr
+		m.SetSize(m.w, m.h)
+	}
+}
+
 func (m Model) View() string {
-	return m.list.View()
+	if m.filterErr != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, m.list.View(),
+			lipgloss.NewStyle().Margin(0, 2).Render(constants.DangerTextStyle.Render(m.filterErr)))
+	}
+	if !m.prompting() {
+		return m.list.View()
+	}
+	hint := constants.DimmedTextStyle.Render(fmt.Sprintf("%d devices • tab format • enter export, to the clipboard when empty • esc cancel", len(m.visiblePeers())))
+	if m.naming {
+		hint = constants.DimmedTextStyle.Render("enter save for this session • esc cancel")
+	}
+	if m.inputErr != "" {
+		hint = constants.DangerTextStyle.Render(m.inputErr)
+	}
+	inputView := lipgloss.NewStyle().Margin(0, 2).Height(inputH).Render(
+		lipgloss.JoinVertical(lipgloss.Left, m.input.View(), hint))
+	return lipgloss.JoinVertical(lipgloss.Left, m.list.View(), inputView)
 }
 
-func New(status *ipnstate.Status, w, h int) Model {
+func New(status *ipnstate.Status, order ts.PeerOrder, grouping ts.PeerGrouping, filters []ts.SavedFilter, w, h int) Model {
 	d := list.NewDefaultDelegate()
 	d.Styles.NormalTitle = lipgloss.NewStyle().Foreground(constants.ColorNormal).Padding(0, 0, 0, 2)
 	d.Styles.NormalDesc = d.Styles.NormalTitle.Foreground(constants.ColorDimmed)
@@ -212,18 +733,30 @@ func New(status *ipnstate.Status, w, h int) Model {
 	d.SetSpacing(1)
 	m := Model{
 		list:       list.New([]list.Item{}, d, w, h),
-		keyMap:     keymap.NewKeyMap(),
+		keyMap:     keymap.NewKeyMap(keymap.ViewNodes),
+		input:      textinput.New(),
 		tailStatus: status,
+		order:      order,
+		grouping:   grouping,
+		collapsed:  map[string]bool{},
+		filters:    filters,
+		filterIdx:  -1,
 		w:          w,
 		h:          h,
 	}
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewNodes)
 	m.list.SetSpinner(spinner.Dot)
 	m.list.StartSpinner()
 	m.list.SetHeight(h)
 
-	m.list.SetItems(m.getItems())
+	m.setItems()
 
-	m.list.Title = "Nodes"
+	m.keyMap.Sort.SetHelp(m.keyMap.Sort.Help().Key, "next sort")
+	m.input.Placeholder = "clipboard"
+	m.list.FilterInput.Placeholder = "text or field:value, e.g. online:true -os:windows"
+	m.input.PromptStyle = constants.PrimaryTextStyle
+	m.input.Cursor.Style = constants.PrimaryTextStyle
+	m.updateTitle()
 	m.list.Styles.Title = constants.PrimaryTitleStyle
 	m.list.FilterInput.PromptStyle = constants.PrimaryTextStyle
 	m.list.FilterInput.Cursor.Style = constants.PrimaryTextStyle
@@ -240,6 +773,20 @@ func New(status *ipnstate.Status, w, h int) Model {
 			m.keyMap.CopyIpv6,
 			m.keyMap.CopyDNSName,
 			m.keyMap.Refresh,
+			m.keyMap.ExitNodes,
+			m.keyMap.Prefs,
+			m.keyMap.Profiles,
+			m.keyMap.Inbox,
+			m.keyMap.Netcheck,
+			m.keyMap.Probe,
+			m.keyMap.Sort,
+			m.keyMap.ReverseSort,
+			m.keyMap.Group,
+			m.keyMap.Collapse,
+			m.keyMap.SavedFilter,
+			m.keyMap.SaveFilter,
+			m.keyMap.Tags,
+			m.keyMap.Export,
 			m.keyMap.Enter,
 		}
 	}
@@ -0,0 +1,314 @@
+package nodelist
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/tuitest"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/types/key"
+)
+
+// rows returns the host names of the peers in items, and the names of the
+// groups prefixed with "#".
+func rows(items []list.Item) []string {
+	var rows []string
+	for _, item := range items {
+		switch item := item.(type) {
+		case groupItem:
+			rows = append(rows, "#"+item.name)
+		case listItem:
+			rows = append(rows, item.status.HostName)
+		}
+	}
+	return rows
+}
+
+func TestGetItemsOrder(t *testing.T) {
+	tests := []struct {
+		order ts.PeerOrder
+		want  []string
+	}{
+		{ts.PeerOrder{}, []string{"laptop", "exit", "server", "ci", "phone"}},
+		{ts.PeerOrder{By: ts.SortHostname}, []string{"laptop", "ci", "exit", "phone", "server"}},
+		{ts.PeerOrder{By: ts.SortHostname, Descending: true}, []string{"laptop", "server", "phone", "exit", "ci"}},
+		{ts.PeerOrder{By: ts.SortOS}, []string{"laptop", "phone", "exit", "server", "ci"}},
+		{ts.PeerOrder{By: ts.SortTraffic, Descending: true}, []string{"laptop", "server", "exit", "ci", "phone"}},
+	}
+	for _, tt := range tests {
+		m := New(ts.FakeStatus(), tt.order, ts.GroupNone, nil, 80, 40)
+		if got := rows(m.getItems()); !slices.Equal(got, tt.want) {
+			t.Errorf("getItems(%s) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestGetItemsGroups(t *testing.T) {
+	tests := []struct {
+		grouping  ts.PeerGrouping
+		order     ts.PeerOrder
+		collapsed []string
+		want      []string
+	}{
+		{
+			grouping: ts.GroupOwner,
+			want:     []string{"#me@example.com", "laptop", "exit", "server", "#alice@example.com", "phone", "#tagged devices", "ci"},
+		},
+		{
+			grouping:  ts.GroupOwner,
+			collapsed: []string{"me@example.com", "tagged devices"},
+			want:      []string{"#me@example.com", "#alice@example.com", "phone", "#tagged devices"},
+		},
+		{
+			grouping: ts.GroupOS,
+			order:    ts.PeerOrder{By: ts.SortHostname},
+			want:     []string{"#android", "phone", "#linux", "laptop", "exit", "server", "#windows", "ci"},
+		},
+		{
+			grouping: ts.GroupTag,
+			want:     []string{"#tag:server", "ci", "#untagged", "laptop", "exit", "server", "phone"},
+		},
+		{
+			grouping: ts.GroupOnline,
+			want:     []string{"#online", "laptop", "exit", "server", "ci", "#offline", "phone"},
+		},
+		{
+			grouping: ts.GroupSharing,
+			want:     []string{"#own devices", "laptop", "exit", "server", "#shared in", "ci", "phone"},
+		},
+	}
+	for _, tt := range tests {
+		m := New(ts.FakeStatus(), tt.order, tt.grouping, nil, 80, 40)
+		for _, name := range tt.collapsed {
+			m.collapsed[name] = true
+		}
+		if got := rows(m.getItems()); !slices.Equal(got, tt.want) {
+			t.Errorf("getItems(%s, collapsed %v) = %v, want %v", tt.grouping, tt.collapsed, got, tt.want)
+		}
+	}
+}
+
+// newHarness returns a sized node list of status.
+func newHarness(t *testing.T, grouping ts.PeerGrouping, filters []ts.SavedFilter) *tuitest.Harness[Model] {
+	status := ts.FakeStatus()
+	h := tuitest.New(t, New(nil, ts.PeerOrder{}, grouping, filters, 80, 40), Model.Update)
+	h.Send(ts.StatusDataMsg(status))
+	return h
+}
+
+// filter types query in the filter and waits for want to show.
+func filter(h *tuitest.Harness[Model], query string, want []string) {
+	h.Send(tuitest.Key("/"), tuitest.Key(query))
+	h.Await(fmt.Sprintf("%q to show %v", query, want), func(m Model) bool {
+		return slices.Equal(rows(m.list.VisibleItems()), want)
+	})
+}
+
+func TestFilterCollapsed(t *testing.T) {
+	h := newHarness(t, ts.GroupOwner, nil)
+	h.Send(tuitest.Key(" "))
+	if got := rows(h.Model.list.Items()); slices.Contains(got, "laptop") {
+		t.Fatalf("rows %v, want the first group collapsed", got)
+	}
+	filter(h, "100.64.0.2", []string{"server"})
+	h.Send(tuitest.Key("enter"))
+	if got := rows(h.Model.list.VisibleItems()); !slices.Equal(got, []string{"server"}) {
+		t.Errorf("rows %v after accepting the filter, want [server]", got)
+	}
+	h.Send(tuitest.Key("esc"))
+	want := []string{"#me@example.com", "#alice@example.com", "phone", "#tagged devices", "ci"}
+	if got := rows(h.Model.list.VisibleItems()); !slices.Equal(got, want) {
+		t.Errorf("rows %v after clearing the filter, want %v", got, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		grouping ts.PeerGrouping
+		query    string
+		want     []string
+	}{
+		{name: "text", query: "android", want: []string{"phone"}},
+		{name: "field", query: "os:linux", want: []string{"laptop", "exit", "server"}},
+		{name: "field and text", query: "online:true windows", want: []string{"ci"}},
+		{name: "negated", query: "-os:linux", want: []string{"ci", "phone"}},
+		{name: "groups", grouping: ts.GroupOwner, query: "user:me", want: []string{"laptop", "exit", "server"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t, tt.grouping, nil)
+			filter(h, tt.query, tt.want)
+			h.Send(tuitest.Key("enter"))
+			if got := rows(h.Model.list.VisibleItems()); !slices.Equal(got, tt.want) {
+				t.Errorf("%q shows %v after accepting it, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterError(t *testing.T) {
+	h := newHarness(t, ts.GroupNone, nil)
+	tests := []struct {
+		key string
+		err string
+	}{
+		{"/", ""},
+		{"o", ""},
+		{"s", ""},
+		{":", "os: missing value"},
+		{"x", ""},
+	}
+	for _, tt := range tests {
+		h.Send(tuitest.Key(tt.key))
+		if m := h.Model; m.filterErr != tt.err {
+			t.Errorf("%q: error %q, want %q", m.list.FilterInput.Value(), m.filterErr, tt.err)
+		}
+	}
+}
+
+func TestSavedFilter(t *testing.T) {
+	filters := []ts.SavedFilter{
+		{Name: "offline", Query: "online:false"},
+		{Name: "linux", Query: "os:linux"},
+	}
+	h := newHarness(t, ts.GroupOnline, filters)
+	tests := [][]string{
+		{"#offline", "phone"},
+		{"#online", "laptop", "exit", "server"},
+		{"#online", "laptop", "exit", "server", "ci", "#offline", "phone"},
+	}
+	for i, want := range tests {
+		h.Send(tuitest.Key("F"))
+		if got := rows(h.Model.list.Items()); !slices.Equal(got, want) {
+			t.Errorf("saved filter %d shows %v, want %v", h.Model.filterIdx, got, want)
+		}
+		if i < len(filters) && !strings.Contains(h.Model.list.Title, filters[i].Name) {
+			t.Errorf("title %q does not name the filter %q", h.Model.list.Title, filters[i].Name)
+		}
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	m := New(ts.FakeStatus(), ts.PeerOrder{}, ts.GroupNone, nil, 80, 40)
+	m.SetTags([]string{"tag:server"})
+	if got := rows(m.list.Items()); !slices.Equal(got, []string{"ci"}) {
+		t.Errorf("tag:server shows %v, want [ci]", got)
+	}
+	if !strings.Contains(m.list.Title, "tag:server") {
+		t.Errorf("title %q does not name the tag", m.list.Title)
+	}
+	m.SetTags(nil)
+	if got := rows(m.list.Items()); len(got) != 5 {
+		t.Errorf("no tags shows %v, want every device", got)
+	}
+}
+
+// publicKey returns the key of the peer named host in status.
+func publicKey(status *ipnstate.Status, host string) key.NodePublic {
+	for _, p := range ts.SortedPeers(status) {
+		if p.HostName == host {
+			return p.PublicKey
+		}
+	}
+	return key.NodePublic{}
+}
+
+func TestGetItemsTopTalkers(t *testing.T) {
+	m := New(ts.FakeStatus(), ts.PeerOrder{By: ts.SortTopTalkers}, ts.GroupNone, nil, 80, 40)
+	m.traffic = ts.TrafficDataMsg{
+		publicKey(m.tailStatus, "server"): {RxRate: 100},
+		publicKey(m.tailStatus, "phone"):  {TxRate: 200},
+	}
+	want := []string{"laptop", "phone", "server", "exit", "ci"}
+	if got := rows(m.getItems()); !slices.Equal(got, want) {
+		t.Errorf("getItems(%s) = %v, want %v", m.order, got, want)
+	}
+}
+
+func TestSaveFilter(t *testing.T) {
+	var saved []FilterSavedMsg
+	update := func(m Model, msg tea.Msg) (Model, tea.Cmd) {
+		if msg, ok := msg.(FilterSavedMsg); ok {
+			saved = append(saved, msg)
+		}
+		return m.Update(msg)
+	}
+	h := tuitest.New(t, New(nil, ts.PeerOrder{}, ts.GroupNone, []ts.SavedFilter{{Name: "offline", Query: "online:false"}}, 80, 40), update)
+	h.Send(ts.StatusDataMsg(ts.FakeStatus()))
+
+	h.Send(tuitest.Key("W"))
+	if h.Model.naming {
+		t.Fatal("naming a filter without a query")
+	}
+	want := []string{"laptop", "exit", "server"}
+	filter(h, "os:linux", want)
+	h.Send(tuitest.Key("enter"), tuitest.Key("W"))
+	if !h.Model.naming {
+		t.Fatal("not naming the filter")
+	}
+	h.Send(tuitest.Key("offline"), tuitest.Key("enter"))
+	if h.Model.inputErr == "" {
+		t.Error("saved a filter under the name of another one")
+	}
+	h.Send(tuitest.Key("esc"), tuitest.Key("W"), tuitest.Key("linux"), tuitest.Key("enter"))
+	h.Await("the filter to be saved", func(Model) bool { return len(saved) == 1 })
+
+	if got := (ts.SavedFilter{Name: "linux", Query: "os:linux"}); ts.SavedFilter(saved[0]) != got {
+		t.Errorf("saved %+v, want %+v", saved[0], got)
+	}
+	m := h.Model
+	if m.naming || m.list.FilterState() != list.Unfiltered {
+		t.Errorf("naming %t, filter state %s, want the saved filter in place of the query", m.naming, m.list.FilterState())
+	}
+	if got := m.filters[m.filterIdx].Name; got != "linux" {
+		t.Errorf("applied the saved filter %q, want linux", got)
+	}
+	if got := rows(m.list.Items()); !slices.Equal(got, want) {
+		t.Errorf("saved filter shows %v, want %v", got, want)
+	}
+}
+
+func TestKeepSelection(t *testing.T) {
+	status := ts.FakeStatus()
+	selected := func(m Model) string {
+		peer, _ := m.selectedPeer()
+		return peer.status.HostName
+	}
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "unfiltered"},
+		{name: "filtered", query: "os:linux"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tuitest.New(t, New(nil, ts.PeerOrder{By: ts.SortTopTalkers}, ts.GroupNone, nil, 80, 40), Model.Update)
+			h.Send(ts.StatusDataMsg(status))
+			if tt.query != "" {
+				filter(h, tt.query, []string{"laptop", "exit", "server"})
+				h.Send(tuitest.Key("enter"))
+			}
+			h.Send(tuitest.Key("j"))
+			if got := selected(h.Model); got != "exit" {
+				t.Fatalf("selected %q, want exit", got)
+			}
+			for _, talker := range []string{"server", "exit"} {
+				h.Model.SetTraffic(ts.TrafficDataMsg{publicKey(status, talker): {RxRate: 100}})
+				h.Send(ts.StatusDataMsg(status))
+				h.Await("exit to stay selected after "+talker+" talks", func(m Model) bool {
+					return m.reselect == "" && selected(m) == "exit"
+				})
+				if got := rows(h.Model.list.VisibleItems()); got[1] != talker {
+					t.Errorf("rows %v, want %s first of the peers", got, talker)
+				}
+			}
+		})
+	}
+}
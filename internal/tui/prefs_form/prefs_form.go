@@ -0,0 +1,298 @@
+package prefsform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
+)
+
+const (
+	fieldHostname = iota
+	fieldShieldsUp
+	fieldAcceptDNS
+	fieldRunSSH
+	fieldTags
+	fieldAutoUpdate
+	fieldOperator
+)
+
+type field struct {
+	label  string
+	isBool bool
+	value  bool
+	input  textinput.Model
+}
+
+type Model struct {
+	backend   ts.Backend
+	original  ts.EditablePrefs
+	loaded    bool
+	fields    []field
+	cursor    int
+	reviewing bool
+	changes   []ts.PrefsChange
+	err       string
+	keyMap    keymap.KeyMap
+	help      help.Model
+	w, h      int
+}
+
+type BackMsg bool
+
+type loadedMsg struct {
+	prefs *ipn.Prefs
+	err   error
+}
+
+type helpKeys struct {
+	keyMap    keymap.KeyMap
+	reviewing bool
+}
+
+func (k helpKeys) ShortHelp() []key.Binding {
+	if k.reviewing {
+		return []key.Binding{k.keyMap.Confirm, k.keyMap.Cancel}
+	}
+	return []key.Binding{k.keyMap.NextField, k.keyMap.PrevField, k.keyMap.Toggle, k.keyMap.Save, k.keyMap.Back}
+}
+
+func (k helpKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	for i := range m.fields {
+		m.fields[i].input.Width = max(w-30, 10)
+	}
+}
+
+func (m *Model) setFields(p ts.EditablePrefs) {
+	m.fields[fieldHostname].input.SetValue(p.Hostname)
+	m.fields[fieldShieldsUp].value = p.ShieldsUp
+	m.fields[fieldAcceptDNS].value = p.AcceptDNS
+	m.fields[fieldRunSSH].value = p.RunSSH
+	m.fields[fieldTags].input.SetValue(strings.Join(p.AdvertiseTags, ","))
+	m.fields[fieldAutoUpdate].value = p.AutoUpdate
+	m.fields[fieldOperator].input.SetValue(p.OperatorUser)
+}
+
+func (m Model) values() (ts.EditablePrefs, error) {
+	tags, err := ts.ParseTags(m.fields[fieldTags].input.Value())
+	if err != nil {
+		return ts.EditablePrefs{}, err
+	}
+	p := ts.EditablePrefs{
+		Hostname:      strings.TrimSpace(m.fields[fieldHostname].input.Value()),
+		ShieldsUp:     m.fields[fieldShieldsUp].value,
+		AcceptDNS:     m.fields[fieldAcceptDNS].value,
+		RunSSH:        m.fields[fieldRunSSH].value,
+		AdvertiseTags: tags,
+		AutoUpdate:    m.fields[fieldAutoUpdate].value,
+		OperatorUser:  strings.TrimSpace(m.fields[fieldOperator].input.Value()),
+	}
+	return p, p.Validate()
+}
+
+func (m Model) dirty() bool {
+	p, err := m.values()
+	return err != nil || len(m.original.Diff(p)) > 0
+}
+
+func (m *Model) focus(i int) tea.Cmd {
+	m.fields[m.cursor].input.Blur()
+	m.cursor = (i + len(m.fields)) % len(m.fields)
+	if !m.fields[m.cursor].isBool {
+		return m.fields[m.cursor].input.Focus()
+	}
+	return nil
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	if m.reviewing {
+		switch {
+		case key.Matches(msg, m.keyMap.Confirm):
+			next, _ := m.values()
+			mp := m.original.MaskedPrefs(next)
+			m.reviewing = false
+			cmds = append(cmds, func() tea.Msg { return ts.EditPrefsMsg(mp) })
+		case key.Matches(msg, m.keyMap.Cancel):
+			m.reviewing = false
+		}
+		return m, cmds
+	}
+	if !m.loaded {
+		if key.Matches(msg, m.keyMap.Back) {
+			cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+		}
+		return m, cmds
+	}
+	current := &m.fields[m.cursor]
+	switch {
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	case key.Matches(msg, m.keyMap.NextField):
+		cmds = append(cmds, m.focus(m.cursor+1))
+	case key.Matches(msg, m.keyMap.PrevField):
+		cmds = append(cmds, m.focus(m.cursor-1))
+	case key.Matches(msg, m.keyMap.Save):
+		next, err := m.values()
+		if err != nil {
+			m.err = err.Error()
+			break
+		}
+		m.changes = m.original.Diff(next)
+		if len(m.changes) == 0 {
+			m.err = "Nothing changed."
+			break
+		}
+		m.reviewing = true
+	case current.isBool && key.Matches(msg, m.keyMap.Toggle):
+		current.value = !current.value
+		m.err = ""
+	case !current.isBool && msg.Type == tea.KeyEnter:
+		cmds = append(cmds, m.focus(m.cursor+1))
+	case !current.isBool:
+		current.input, cmd = current.input.Update(msg)
+		cmds = append(cmds, cmd)
+		m.err = ""
+	}
+	return m, cmds
+}
+
+func (m Model) Init() tea.Cmd {
+	return func() tea.Msg {
+		prefs, err := ts.GetPrefs(m.backend)
+		return loadedMsg{prefs: prefs, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case loadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Sprintf("Could not load preferences: %s", msg.err)
+			break
+		}
+		m.original = ts.NewEditablePrefs(msg.prefs)
+		m.setFields(m.original)
+		m.loaded = true
+		cmds = append(cmds, m.focus(m.cursor))
+	case ts.PrefsDataMsg:
+		if !m.loaded {
+			break
+		}
+		wasDirty := m.dirty()
+		m.original = ts.NewEditablePrefs(msg)
+		if !wasDirty {
+			m.setFields(m.original)
+		}
+	case tea.KeyMsg:
+		var kcmds []tea.Cmd
+		m, kcmds = m.keyBindingsHandler(msg)
+		cmds = append(cmds, kcmds...)
+	default:
+		if m.loaded && !m.fields[m.cursor].isBool {
+			m.fields[m.cursor].input, cmd = m.fields[m.cursor].input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) fieldsView() string {
+	if !m.loaded {
+		return constants.DimmedTextStyle.Render("Loading preferences...")
+	}
+	var rows []string
+	for i, f := range m.fields {
+		cursor := "  "
+		labelStyle := constants.NormalTextStyle
+		if i == m.cursor && !m.reviewing {
+			cursor = constants.PrimaryTextStyle.Render("> ")
+			labelStyle = constants.PrimaryTextStyle
+		}
+		value := f.input.View()
+		if f.isBool {
+			value = constants.DimmedTextStyle.Render("[ ] no")
+			if f.value {
+				value = constants.SuccessTextStyle.Render("[x] yes")
+			}
+		}
+		rows = append(rows, cursor+labelStyle.Width(18).Render(f.label)+value)
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (m Model) reviewView() string {
+	rows := []string{constants.WarningTitleStyle.Render("Review changes"), ""}
+	for _, c := range m.changes {
+		old := c.Old
+		if old == "" {
+			old = "(empty)"
+		}
+		new := c.New
+		if new == "" {
+			new = "(empty)"
+		}
+		rows = append(rows, fmt.Sprintf("  %s %s → %s",
+			constants.SecondaryTextStyle.Width(18).Render(c.Field+":"),
+			constants.DangerTextStyle.Render(old),
+			constants.SuccessTextStyle.Render(new)))
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (m Model) View() string {
+	sections := []string{constants.PrimaryTitleStyle.Render("Preferences"), "", m.fieldsView(), ""}
+	if m.err != "" {
+		sections = append(sections, constants.DangerTextStyle.Render(m.err), "")
+	}
+	if m.reviewing {
+		sections = append(sections, m.reviewView(), "")
+	}
+	sections = append(sections, m.help.View(helpKeys{keyMap: m.keyMap, reviewing: m.reviewing}))
+	return lipgloss.NewStyle().Margin(0, 2).Height(m.h).Render(lipgloss.JoinVertical(lipgloss.Left, sections...))
+}
+
+func newTextField(label, placeholder string) field {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = placeholder
+	input.Cursor.Style = constants.PrimaryTextStyle
+	return field{label: label, input: input}
+}
+
+func New(backend ts.Backend, w, h int) Model {
+	m := Model{
+		backend: backend,
+		keyMap:  keymap.NewKeyMap(keymap.ViewPrefs),
+		help:    help.New(),
+		fields: []field{
+			fieldHostname:   newTextField("Hostname", "OS hostname"),
+			fieldShieldsUp:  {label: "Shields up", isBool: true},
+			fieldAcceptDNS:  {label: "Accept DNS", isBool: true},
+			fieldRunSSH:     {label: "Run SSH server", isBool: true},
+			fieldTags:       newTextField("Advertise tags", "tag:server,tag:ci"),
+			fieldAutoUpdate: {label: "Auto-update", isBool: true},
+			fieldOperator:   newTextField("Operator user", "none"),
+		},
+	}
+	m.keyMap.Back.SetHelp(m.keyMap.Back.Help().Key, "back")
+	m.SetSize(w, h)
+	return m
+}
@@ -0,0 +1,211 @@
+package profilelist
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
+)
+
+type listItem struct {
+	title, desc string
+	profile     ipn.LoginProfile
+}
+
+func (i listItem) Title() string       { return i.title }
+func (i listItem) Description() string { return i.desc }
+func (i listItem) FilterValue() string { return i.title + " " + i.desc }
+
+type Model struct {
+	current  ipn.LoginProfile
+	profiles []ipn.LoginProfile
+	list     list.Model
+	keyMap   keymap.KeyMap
+	deleting *ipn.LoginProfile
+	w        int
+	h        int
+}
+
+type BackMsg bool
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	m.list.SetSize(w, h)
+}
+
+func (m *Model) updateKeybindings() {
+	filtering := m.list.FilterState() == list.Filtering
+	hasItem := m.list.SelectedItem() != nil
+	m.keyMap.Enter.SetEnabled(!filtering && hasItem)
+	m.keyMap.Delete.SetEnabled(!filtering && hasItem)
+	m.keyMap.NewProfile.SetEnabled(!filtering)
+	m.keyMap.Back.SetEnabled(!filtering)
+	m.list.KeyMap.NextPage.SetEnabled(false)
+	m.list.KeyMap.PrevPage.SetEnabled(false)
+	m.list.KeyMap.Quit.SetEnabled(false)
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.deleting != nil {
+		switch {
+		case key.Matches(msg, m.keyMap.Confirm):
+			id := m.deleting.ID
+			cmds = append(cmds, func() tea.Msg { return ts.DeleteProfileMsg(id) })
+			m.deleting = nil
+		case key.Matches(msg, m.keyMap.Cancel):
+			m.deleting = nil
+		}
+		m.updateStatus()
+		return m, cmds
+	}
+	switch {
+	case key.Matches(msg, m.keyMap.Enter):
+		profile := m.list.SelectedItem().(listItem).profile
+		if profile.ID != m.current.ID {
+			cmds = append(cmds, func() tea.Msg { return ts.SwitchProfileMsg(profile.ID) })
+		}
+	case key.Matches(msg, m.keyMap.Delete):
+		profile := m.list.SelectedItem().(listItem).profile
+		m.deleting = &profile
+		m.updateStatus()
+	case key.Matches(msg, m.keyMap.NewProfile):
+		cmds = append(cmds, func() tea.Msg { return ts.NewProfileMsg(true) })
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	}
+	return m, cmds
+}
+
+func (m *Model) getItems() []list.Item {
+	items := []list.Item{}
+	for _, p := range m.profiles {
+		current := ""
+		if p.ID == m.current.ID {
+			current = constants.SuccessTextStyle.Bold(true).Render("[current]")
+		}
+		login := p.UserProfile.LoginName
+		if login == "" {
+			login = p.Name
+		}
+		title := fmt.Sprintf("%s %s", login, current)
+		details := []string{ts.ProfileTailnet(p)}
+		if p.UserProfile.DisplayName != "" {
+			details = append(details, p.UserProfile.DisplayName)
+		}
+		if p.ControlURL != "" && p.ControlURL != ipn.DefaultControlURL {
+			details = append(details, p.ControlURL)
+		}
+		desc := "- " + strings.Join(details, " | ")
+		items = append(items, listItem{title: title, desc: desc, profile: p})
+	}
+	return items
+}
+
+func (m *Model) updateStatus() {
+	if m.deleting != nil {
+		m.list.NewStatusMessage(constants.WarningTextStyle.Render(
+			fmt.Sprintf("Delete profile %s? (y/n)", m.deleting.UserProfile.LoginName)))
+		return
+	}
+	m.list.NewStatusMessage("")
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.ProfilesDataMsg:
+		m.current = msg.Current
+		m.profiles = msg.All
+		m.list.StopSpinner()
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+		for i, item := range m.list.Items() {
+			if item.(listItem).profile.ID == m.current.ID {
+				m.list.Select(i)
+			}
+		}
+	case tea.KeyMsg:
+		if m.list.FilterState() != list.Filtering {
+			var kcmds []tea.Cmd
+			deleting := m.deleting != nil
+			m, kcmds = m.keyBindingsHandler(msg)
+			cmds = append(cmds, kcmds...)
+			if deleting {
+				return m, tea.Batch(cmds...)
+			}
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	m.updateKeybindings()
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	return m.list.View()
+}
+
+func New(w, h int) Model {
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = lipgloss.NewStyle().Foreground(constants.ColorNormal).Padding(0, 0, 0, 2)
+	d.Styles.NormalDesc = d.Styles.NormalTitle.Foreground(constants.ColorDimmed)
+	d.Styles.SelectedTitle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(constants.ColorPrimary).
+		Foreground(constants.ColorPrimary).
+		Padding(0, 0, 0, 1)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle
+	d.Styles.DimmedTitle = constants.DimmedTextStyle.Padding(0, 0, 0, 2)
+	d.Styles.DimmedDesc = d.Styles.DimmedTitle.Foreground(constants.ColorMuted)
+	d.SetHeight(2)
+	d.SetSpacing(1)
+	m := Model{
+		list:   list.New([]list.Item{}, d, w, h),
+		keyMap: keymap.NewKeyMap(keymap.ViewProfiles),
+		w:      w,
+		h:      h,
+	}
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewProfiles)
+	m.keyMap.Enter.SetHelp(m.keyMap.Enter.Help().Key, "switch")
+	m.keyMap.Delete.SetHelp(m.keyMap.Delete.Help().Key, "delete profile")
+	m.list.SetSpinner(spinner.Dot)
+	m.list.StartSpinner()
+
+	m.list.Title = "Profiles"
+	m.list.Styles.Title = constants.PrimaryTitleStyle
+	m.list.FilterInput.PromptStyle = constants.PrimaryTextStyle
+	m.list.FilterInput.Cursor.Style = constants.PrimaryTextStyle
+	m.list.SetStatusBarItemName("profile", "profiles")
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.Enter,
+			m.keyMap.NewProfile,
+			m.keyMap.Back,
+		}
+	}
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.Enter,
+			m.keyMap.NewProfile,
+			m.keyMap.Delete,
+			m.keyMap.Back,
+		}
+	}
+	m.updateKeybindings()
+	return m
+}
@@ -0,0 +1,249 @@
+package routelist
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+)
+
+type listItem struct {
+	title, desc string
+	route       netip.Prefix
+}
+
+func (i listItem) Title() string       { return i.title }
+func (i listItem) Description() string { return i.desc }
+func (i listItem) FilterValue() string { return i.title }
+
+type Model struct {
+	tailStatus *ipnstate.Status
+	prefs      *ipn.Prefs
+	list       list.Model
+	keyMap     keymap.KeyMap
+	input      textinput.Model
+	adding     bool
+	inputErr   string
+	w          int
+	h          int
+}
+
+type BackMsg bool
+
+const inputH = 3
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	if m.adding {
+		h -= inputH
+	}
+	m.list.SetSize(w, h)
+}
+
+func (m *Model) updateKeybindings() {
+	filtering := m.list.FilterState() == list.Filtering
+	m.keyMap.AddRoute.SetEnabled(!filtering && m.prefs != nil)
+	m.keyMap.RemoveRoute.SetEnabled(!filtering && m.list.SelectedItem() != nil)
+	m.keyMap.AcceptRoutes.SetEnabled(!filtering && m.prefs != nil)
+	m.keyMap.Back.SetEnabled(!filtering)
+	m.list.KeyMap.NextPage.SetEnabled(false)
+	m.list.KeyMap.PrevPage.SetEnabled(false)
+	m.list.KeyMap.Quit.SetEnabled(false)
+}
+
+func (m *Model) startAdding() tea.Cmd {
+	m.adding = true
+	m.inputErr = ""
+	m.input.Reset()
+	m.SetSize(m.w, m.h)
+	return m.input.Focus()
+}
+
+func (m *Model) stopAdding() {
+	m.adding = false
+	m.input.Blur()
+	m.SetSize(m.w, m.h)
+}
+
+func (m Model) inputHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.stopAdding()
+	case tea.KeyEnter:
+		route, err := ts.ParseRoute(m.input.Value())
+		if err == nil && slices.Contains(m.prefs.AdvertiseRoutes, route) {
+			err = fmt.Errorf("%s is already advertised", route)
+		}
+		if err != nil {
+			m.inputErr = err.Error()
+			break
+		}
+		m.stopAdding()
+		cmds = append(cmds, func() tea.Msg { return ts.AdvertiseRouteMsg(route) })
+	default:
+		m.inputErr = ""
+		m.input, cmd = m.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, cmds
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	switch {
+	case key.Matches(msg, m.keyMap.AddRoute):
+		cmds = append(cmds, m.startAdding())
+	case key.Matches(msg, m.keyMap.RemoveRoute):
+		route := m.list.SelectedItem().(listItem).route
+		cmds = append(cmds, func() tea.Msg { return ts.UnadvertiseRouteMsg(route) })
+	case key.Matches(msg, m.keyMap.AcceptRoutes):
+		accept := !m.prefs.RouteAll
+		cmds = append(cmds, func() tea.Msg { return ts.AcceptRoutesMsg(accept) })
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	}
+	return m, cmds
+}
+
+func (m *Model) getItems() []list.Item {
+	items := []list.Item{}
+	if m.tailStatus == nil {
+		return items
+	}
+	for _, route := range ts.SubnetRoutes(m.prefs) {
+		desc := "- " + constants.SuccessTextStyle.Render("approved and active")
+		if !ts.RouteApproved(m.tailStatus.Self.PrimaryRoutes, route) {
+			desc = "- " + constants.WarningTextStyle.Render("not approved or not active")
+		}
+		items = append(items, listItem{title: route.String(), desc: desc, route: route})
+	}
+	return items
+}
+
+func (m *Model) updateStatus() {
+	accept := constants.DangerTextStyle.Render("off")
+	if m.prefs != nil && m.prefs.RouteAll {
+		accept = constants.SuccessTextStyle.Render("on")
+	}
+	m.list.NewStatusMessage(constants.DimmedTextStyle.Render("Accept routes: ") + accept)
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.StatusDataMsg:
+		m.tailStatus = msg
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+	case ts.PrefsDataMsg:
+		m.prefs = msg
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+		m.updateStatus()
+	case tea.KeyMsg:
+		var kcmds []tea.Cmd
+		if m.adding {
+			m, kcmds = m.inputHandler(msg)
+			return m, tea.Batch(kcmds...)
+		}
+		if m.list.FilterState() != list.Filtering {
+			m, kcmds = m.keyBindingsHandler(msg)
+			cmds = append(cmds, kcmds...)
+		}
+	default:
+		if m.adding {
+			m.input, cmd = m.input.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	m.updateKeybindings()
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if !m.adding {
+		return m.list.View()
+	}
+	errView := ""
+	if m.inputErr != "" {
+		errView = constants.DangerTextStyle.Render(m.inputErr)
+	}
+	inputView := lipgloss.NewStyle().Margin(0, 2).Height(inputH).Render(
+		lipgloss.JoinVertical(lipgloss.Left, m.input.View(), errView))
+	return lipgloss.JoinVertical(lipgloss.Left, m.list.View(), inputView)
+}
+
+func New(status *ipnstate.Status, prefs *ipn.Prefs, w, h int) Model {
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = lipgloss.NewStyle().Foreground(constants.ColorNormal).Padding(0, 0, 0, 2)
+	d.Styles.NormalDesc = d.Styles.NormalTitle.Foreground(constants.ColorDimmed)
+	d.Styles.SelectedTitle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(constants.ColorPrimary).
+		Foreground(constants.ColorPrimary).
+		Padding(0, 0, 0, 1)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle
+	d.Styles.DimmedTitle = constants.DimmedTextStyle.Padding(0, 0, 0, 2)
+	d.Styles.DimmedDesc = d.Styles.DimmedTitle.Foreground(constants.ColorMuted)
+	d.SetHeight(2)
+	d.SetSpacing(1)
+	m := Model{
+		list:       list.New([]list.Item{}, d, w, h),
+		keyMap:     keymap.NewKeyMap(keymap.ViewRoutes),
+		input:      textinput.New(),
+		tailStatus: status,
+		prefs:      prefs,
+		w:          w,
+		h:          h,
+	}
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewRoutes)
+	m.input.Prompt = "Route: "
+	m.input.Placeholder = "10.0.0.0/24"
+	m.input.PromptStyle = constants.PrimaryTextStyle
+	m.input.Cursor.Style = constants.PrimaryTextStyle
+	m.list.SetItems(m.getItems())
+
+	m.list.Title = "Subnet Routes"
+	m.list.Styles.Title = constants.PrimaryTitleStyle
+	m.list.FilterInput.PromptStyle = constants.PrimaryTextStyle
+	m.list.FilterInput.Cursor.Style = constants.PrimaryTextStyle
+	m.list.SetStatusBarItemName("advertised route", "advertised routes")
+	m.updateStatus()
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.AddRoute,
+			m.keyMap.RemoveRoute,
+			m.keyMap.AcceptRoutes,
+			m.keyMap.Back,
+		}
+	}
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.AddRoute,
+			m.keyMap.RemoveRoute,
+			m.keyMap.AcceptRoutes,
+			m.keyMap.Back,
+		}
+	}
+	m.updateKeybindings()
+	return m
+}
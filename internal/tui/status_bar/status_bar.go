@@ -66,15 +66,16 @@ func (m Model) View() string {
 	return m.barStyle.Render(prefixView + msgView + suffixView)
 }
 
+// Restyle takes the current theme into use.
+func (m *Model) Restyle() {
+	m.barStyle = lipgloss.NewStyle().Background(constants.ColorNormalInv).Margin(1, 0).Height(1).Width(m.w)
+	m.prefixStyle = constants.PrimaryTitleStyle
+	m.msgStyle = lipgloss.NewStyle().Background(m.barStyle.GetBackground()).Foreground(constants.ColorBW)
+	m.suffixStyle = constants.SecondaryTitleStyle
+}
+
 func New() Model {
-	barStyle := lipgloss.NewStyle().Background(constants.ColorNormalInv).Margin(1, 0).Height(1)
-	return Model{
-		barStyle:    barStyle,
-		prefix:      "TAILSCALE-TUI",
-		prefixStyle: constants.PrimaryTitleStyle,
-		msg:         "",
-		msgStyle:    lipgloss.NewStyle().Background(barStyle.GetBackground()).Foreground(constants.ColorBW),
-		suffix:      "",
-		suffixStyle: constants.SecondaryTitleStyle,
-	}
+	m := Model{prefix: "TAILSCALE-TUI"}
+	m.Restyle()
+	return m
 }
@@ -0,0 +1,204 @@
+package taglist
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/keymap"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn/ipnstate"
+)
+
+type listItem struct {
+	title, desc string
+	tag         string
+}
+
+func (i listItem) Title() string       { return i.title }
+func (i listItem) Description() string { return i.desc }
+func (i listItem) FilterValue() string { return i.tag }
+
+type Model struct {
+	tailStatus *ipnstate.Status
+	selected   map[string]bool
+	list       list.Model
+	keyMap     keymap.KeyMap
+	w          int
+	h          int
+}
+
+type BackMsg bool
+
+// TagsSelectedMsg filters the node list by the tags picked, none clears the
+// filter.
+type TagsSelectedMsg []string
+
+func (m *Model) SetSize(w int, h int) {
+	m.w = w
+	m.h = h
+	m.list.SetSize(w, h)
+}
+
+func (m *Model) updateKeybindings() {
+	filtering := m.list.FilterState() == list.Filtering
+	m.keyMap.Toggle.SetEnabled(!filtering && m.list.SelectedItem() != nil)
+	m.keyMap.Enter.SetEnabled(!filtering)
+	m.keyMap.Back.SetEnabled(!filtering)
+	m.list.KeyMap.NextPage.SetEnabled(false)
+	m.list.KeyMap.PrevPage.SetEnabled(false)
+	m.list.KeyMap.Quit.SetEnabled(false)
+}
+
+// selectedTags returns the tags picked, in the order of the list.
+func (m Model) selectedTags() []string {
+	var tags []string
+	for _, tag := range ts.TailnetTags(m.tailStatus) {
+		if m.selected[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (m Model) keyBindingsHandler(msg tea.KeyMsg) (Model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	switch {
+	case key.Matches(msg, m.keyMap.Toggle):
+		tag := m.list.SelectedItem().(listItem).tag
+		m.selected[tag] = !m.selected[tag]
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+		m.updateTitle()
+	case key.Matches(msg, m.keyMap.Enter):
+		tags := m.selectedTags()
+		cmds = append(cmds, func() tea.Msg { return TagsSelectedMsg(tags) })
+	case key.Matches(msg, m.keyMap.Back):
+		cmds = append(cmds, func() tea.Msg { return BackMsg(true) })
+	}
+	return m, cmds
+}
+
+func (m *Model) getItems() []list.Item {
+	items := []list.Item{}
+	if m.tailStatus == nil {
+		return items
+	}
+
+	for _, tag := range ts.TailnetTags(m.tailStatus) {
+		total, online := 0, 0
+		for _, p := range ts.SortedPeers(m.tailStatus) {
+			if ts.HasAnyTag(p, []string{tag}) {
+				total++
+				if p.Online {
+					online++
+				}
+			}
+		}
+		check := "[ ]"
+		if m.selected[tag] {
+			check = constants.SuccessTextStyle.Bold(true).Render("[x]")
+		}
+		title := fmt.Sprintf("%s %s", check, tag)
+		desc := fmt.Sprintf("- %d/%d online", online, total)
+		items = append(items, listItem{title: title, desc: desc, tag: tag})
+	}
+	return items
+}
+
+func (m *Model) updateTitle() {
+	m.list.SetStatusBarItemName("tag", "tags")
+	m.list.NewStatusMessage(constants.DimmedTextStyle.Render(fmt.Sprintf("%d selected", len(m.selectedTags()))))
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case ts.StatusDataMsg:
+		m.tailStatus = msg
+		cmds = append(cmds, m.list.SetItems(m.getItems()))
+		m.updateTitle()
+	case tea.KeyMsg:
+		if m.list.FilterState() != list.Filtering {
+			var kcmds []tea.Cmd
+			m, kcmds = m.keyBindingsHandler(msg)
+			cmds = append(cmds, kcmds...)
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	m.updateKeybindings()
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	return m.list.View()
+}
+
+func New(status *ipnstate.Status, tags []string, w, h int) Model {
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = lipgloss.NewStyle().Foreground(constants.ColorNormal).Padding(0, 0, 0, 2)
+	d.Styles.NormalDesc = d.Styles.NormalTitle.Foreground(constants.ColorDimmed)
+	d.Styles.SelectedTitle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(constants.ColorPrimary).
+		Foreground(constants.ColorPrimary).
+		Padding(0, 0, 0, 1)
+	d.Styles.SelectedDesc = d.Styles.SelectedTitle
+	d.Styles.DimmedTitle = constants.DimmedTextStyle.Padding(0, 0, 0, 2)
+	d.Styles.DimmedDesc = d.Styles.DimmedTitle.Foreground(constants.ColorMuted)
+	d.SetHeight(2)
+	d.SetSpacing(1)
+	m := Model{
+		list:       list.New([]list.Item{}, d, w, h),
+		keyMap:     keymap.NewKeyMap(keymap.ViewTags),
+		tailStatus: status,
+		selected:   map[string]bool{},
+		w:          w,
+		h:          h,
+	}
+	for _, tag := range tags {
+		m.selected[tag] = true
+	}
+	m.list.KeyMap = keymap.NewListKeyMap(keymap.ViewTags)
+	m.keyMap.Toggle.SetHelp(m.keyMap.Toggle.Help().Key, "toggle tag")
+	m.keyMap.Enter.SetHelp(m.keyMap.Enter.Help().Key, "apply")
+	m.list.SetItems(m.getItems())
+	for i, item := range m.list.Items() {
+		if slices.Contains(tags, item.(listItem).tag) {
+			m.list.Select(i)
+			break
+		}
+	}
+
+	m.list.Title = "Tags"
+	m.list.Styles.Title = constants.PrimaryTitleStyle
+	m.list.FilterInput.PromptStyle = constants.PrimaryTextStyle
+	m.list.FilterInput.Cursor.Style = constants.PrimaryTextStyle
+	m.updateTitle()
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.Toggle,
+			m.keyMap.Enter,
+			m.keyMap.Back,
+		}
+	}
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keyMap.Toggle,
+			m.keyMap.Enter,
+			m.keyMap.Back,
+		}
+	}
+	m.updateKeybindings()
+	return m
+}
@@ -0,0 +1,30 @@
+package taglist
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/tuitest"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSelectTags(t *testing.T) {
+	var picked TagsSelectedMsg
+	update := func(m Model, msg tea.Msg) (Model, tea.Cmd) {
+		if msg, ok := msg.(TagsSelectedMsg); ok {
+			picked = msg
+		}
+		return m.Update(msg)
+	}
+	h := tuitest.New(t, New(ts.FakeStatus(), nil, 80, 40), update)
+	h.Send(tuitest.Key(" "))
+	if got := h.Model.selectedTags(); !slices.Equal(got, []string{"tag:server"}) {
+		t.Errorf("selected %v after toggling, want [tag:server]", got)
+	}
+	h.Send(tuitest.Key("enter"))
+	h.Await("the tags to be applied", func(Model) bool { return picked != nil })
+	if !slices.Equal(picked, TagsSelectedMsg{"tag:server"}) {
+		t.Errorf("applied %v, want [tag:server]", picked)
+	}
+}
@@ -1,19 +1,35 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/bilguun0203/tailscale-tui/internal/config"
 	"github.com/bilguun0203/tailscale-tui/internal/ts"
 	"github.com/bilguun0203/tailscale-tui/internal/tui/constants"
+	exitnodelist "github.com/bilguun0203/tailscale-tui/internal/tui/exit_node_list"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/inbox"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/login"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/netcheck"
 	nodedetails "github.com/bilguun0203/tailscale-tui/internal/tui/node_details"
 	nodelist "github.com/bilguun0203/tailscale-tui/internal/tui/node_list"
+	prefsform "github.com/bilguun0203/tailscale-tui/internal/tui/prefs_form"
+	profilelist "github.com/bilguun0203/tailscale-tui/internal/tui/profile_list"
+	routelist "github.com/bilguun0203/tailscale-tui/internal/tui/route_list"
 	statusbar "github.com/bilguun0203/tailscale-tui/internal/tui/status_bar"
+	taglist "github.com/bilguun0203/tailscale-tui/internal/tui/tag_list"
 	"github.com/bilguun0203/tailscale-tui/internal/tui/types"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/client/tailscale/apitype"
+	"tailscale.com/ipn"
 	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tailcfg"
 	tsKey "tailscale.com/types/key"
 )
 
@@ -22,24 +38,128 @@ type viewState int
 const (
 	viewStateList viewState = iota
 	viewStateDetails
+	viewStateExitNodes
+	viewStateRoutes
+	viewStatePrefs
+	viewStateProfiles
+	viewStateLogin
+	viewStateInbox
+	viewStateNetcheck
+	viewStateTags
 )
 
+type busRefreshMsg struct{}
+
+// busWatchMsg is a new subscription to the IPN bus.
+type busWatchMsg struct{ w ts.BusWatcher }
+
+// busRetryMsg subscribes to the IPN bus again after a backoff.
+type busRetryMsg struct{}
+
+type profileSwitchedMsg string
+
+// loginPollMsg refreshes the status while waiting for a login to complete
+// without a working IPN bus connection.
+type loginPollMsg struct{}
+
+// configPollMsg checks whether the config file changed.
+type configPollMsg struct{}
+
+const configPollInterval = 2 * time.Second
+
+type configLoadedMsg config.Config
+
+type configErrorMsg struct{ err error }
+
+type taildropDoneMsg string
+
+// connectTimeoutMsg stops waiting for a connect or disconnect, timeouts of
+// a previous gen are dropped.
+type connectTimeoutMsg struct {
+	gen int
+}
+
+const connectTimeout = 30 * time.Second
+
+// trafficTickMsg refreshes the status to update the traffic rates, ticks of
+// a previous gen are dropped.
+type trafficTickMsg struct {
+	gen int
+}
+
+// probeDoneMsg and probeTickMsg belong to the probing started with ctx, they
+// are dropped once it is stopped.
+type probeDoneMsg struct {
+	ctx       context.Context
+	latencies ts.ProbeDataMsg
+}
+
+type probeTickMsg struct {
+	ctx context.Context
+}
+
+type prefsEditedMsg struct {
+	prefs  *ipn.Prefs
+	status string
+}
+
 func (f viewState) String() string {
 	return [...]string{
 		"list",
 		"details",
+		"exit nodes",
+		"routes",
+		"prefs",
+		"profiles",
+		"login",
+		"inbox",
+		"netcheck",
+		"tags",
 	}[f]
 }
 
 type Model struct {
+	backend        ts.Backend
 	viewState      viewState
+	returnView     viewState
 	tsStatus       *ipnstate.Status
+	prefs          *ipn.Prefs
 	selectedNodeID tsKey.NodePublic
 	isLoading      bool
+	wantRunning    *bool
+	connectGen     int
+	busCtx         context.Context
+	stopBus        context.CancelFunc
+	bus            ts.BusWatcher
+	busBackoff     time.Duration
+	busConnected   bool
+	refreshPending bool
+	waitingFiles   []apitype.WaitingFile
+	pingOpts       ts.PingOptions
+	probeOpts      ts.ProbeOptions
+	peerOrder      ts.PeerOrder
+	peerGrouping   ts.PeerGrouping
+	savedFilters   []ts.SavedFilter
+	sessionFilters []ts.SavedFilter
+	refresh        config.Refresh
+	watcher        *config.Watcher
+	stopProbe      context.CancelFunc
+	latencies      ts.ProbeDataMsg
+	trafficMeter   ts.TrafficMeter
+	traffic        ts.TrafficDataMsg
+	trafficGen     int
 	Err            error
 	ExitMessage    string
 	nodelist       nodelist.Model
 	nodedetails    nodedetails.Model
+	exitnodelist   exitnodelist.Model
+	routelist      routelist.Model
+	prefsform      prefsform.Model
+	profilelist    profilelist.Model
+	login          login.Model
+	inbox          inbox.Model
+	netcheck       netcheck.Model
+	taglist        taglist.Model
 	statusbar      statusbar.Model
 	spinner        spinner.Model
 	w, h           int
@@ -49,7 +169,7 @@ type Model struct {
 
 func (m Model) getTsStatus() tea.Cmd {
 	return func() tea.Msg {
-		status, err := ts.GetStatus()
+		status, err := ts.GetStatus(m.backend)
 		if err != nil {
 			return ts.StatusErrorMsg(err)
 		}
@@ -57,17 +177,291 @@ func (m Model) getTsStatus() tea.Cmd {
 	}
 }
 
+func (m Model) watchBus() tea.Cmd {
+	ctx, backoff := m.busCtx, m.busBackoff
+	return func() tea.Msg {
+		w, err := ts.WatchBus(ctx, m.backend)
+		if err != nil {
+			return ts.BusErrorMsg{Err: err, RetryIn: backoff}
+		}
+		return busWatchMsg{w: w}
+	}
+}
+
+// waitForBus blocks until the next notification, the subscription is
+// closed when the stream drops.
+func (m Model) waitForBus() tea.Cmd {
+	w, backoff := m.bus, m.busBackoff
+	return func() tea.Msg {
+		n, err := w.Next()
+		if err != nil {
+			w.Close()
+			return ts.BusErrorMsg{Err: err, RetryIn: backoff}
+		}
+		return ts.BusNotifyMsg(&n)
+	}
+}
+
+// Close stops watching the IPN bus, for when the program quits.
+func (m Model) Close() {
+	m.stopBus()
+}
+
+func (m *Model) scheduleRefresh() tea.Cmd {
+	if m.refreshPending {
+		return nil
+	}
+	m.refreshPending = true
+	return tea.Tick(m.refresh.BusDelay, func(time.Time) tea.Msg { return busRefreshMsg{} })
+}
+
+func (m Model) setTSStatus(status bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := ts.SetTSStatus(m.backend, status); err != nil {
+			return ts.PrefsErrorMsg(err)
+		}
+		return ts.ConnectMsg(status)
+	}
+}
+
+func (m Model) getPrefs() tea.Cmd {
+	return func() tea.Msg {
+		prefs, err := ts.GetPrefs(m.backend)
+		if err != nil {
+			return ts.PrefsErrorMsg(err)
+		}
+		return ts.PrefsDataMsg(prefs)
+	}
+}
+
+func (m Model) getProfiles() tea.Cmd {
+	return func() tea.Msg {
+		current, all, err := ts.GetProfiles(m.backend)
+		if err != nil {
+			return ts.ProfileErrorMsg(err)
+		}
+		return ts.ProfilesDataMsg{Current: current, All: all}
+	}
+}
+
+// editProfiles runs edit in the background and reloads the profiles, prefs
+// and status afterwards, showing done in the status bar on success.
+func (m Model) editProfiles(edit func(ts.Backend) error, done string) tea.Cmd {
+	return func() tea.Msg {
+		if err := edit(m.backend); err != nil {
+			return ts.ProfileErrorMsg(err)
+		}
+		return profileSwitchedMsg(done)
+	}
+}
+
+func (m Model) getWaitingFiles() tea.Cmd {
+	return func() tea.Msg {
+		files, err := ts.GetWaitingFiles(m.backend)
+		if err != nil {
+			return ts.TaildropErrorMsg(err)
+		}
+		return ts.WaitingFilesMsg(files)
+	}
+}
+
+// editInbox runs edit in the background and reloads the waiting files
+// afterwards, showing the returned message in the status bar on success.
+func (m Model) editInbox(edit func(ts.Backend) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		done, err := edit(m.backend)
+		if err != nil {
+			return ts.TaildropErrorMsg(err)
+		}
+		return taildropDoneMsg(done)
+	}
+}
+
+func (m Model) runNetcheck() tea.Cmd {
+	return func() tea.Msg {
+		report, err := ts.Netcheck(m.backend)
+		if err != nil {
+			return ts.NetcheckErrorMsg(err)
+		}
+		return ts.NetcheckDataMsg(report)
+	}
+}
+
+func (m Model) probePeers(ctx context.Context) tea.Cmd {
+	b, status, opts := m.backend, m.tsStatus, m.probeOpts
+	return func() tea.Msg {
+		return probeDoneMsg{ctx: ctx, latencies: ts.ProbePeers(ctx, b, status, opts)}
+	}
+}
+
+func (m *Model) startProbing() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopProbe = cancel
+	return tea.Batch(m.probePeers(ctx), types.NewStatusMsg("Probing latency of online peers..."))
+}
+
+func (m *Model) stopProbing() tea.Cmd {
+	m.stopProbe()
+	m.stopProbe = nil
+	return tea.Batch(
+		func() tea.Msg { return ts.ProbeDataMsg(nil) },
+		types.NewStatusMsg("Latency probing stopped."))
+}
+
+func (m Model) pollConfig() tea.Cmd {
+	return tea.Tick(configPollInterval, func(time.Time) tea.Msg { return configPollMsg{} })
+}
+
+func (m Model) loadConfig() tea.Cmd {
+	w := m.watcher
+	return func() tea.Msg {
+		cfg, err := w.Load()
+		if err != nil {
+			return configErrorMsg{err}
+		}
+		return configLoadedMsg(cfg)
+	}
+}
+
+// pollTraffic schedules the next status refresh for the traffic rates, if
+// enabled.
+func (m Model) pollTraffic() tea.Cmd {
+	if m.refresh.Traffic <= 0 {
+		return nil
+	}
+	gen := m.trafficGen
+	return tea.Tick(m.refresh.Traffic, func(time.Time) tea.Msg { return trafficTickMsg{gen: gen} })
+}
+
+// applyConfig takes the new settings into use. Views opened afterwards get
+// the new keys and colors, the node list is created again to get them now.
+func (m *Model) applyConfig(cfg config.Config) tea.Cmd {
+	cfg.Apply()
+	m.pingOpts = cfg.PingOptions()
+	m.probeOpts = cfg.ProbeOptions()
+	restartTraffic := cfg.Refresh.Traffic != m.refresh.Traffic
+	m.refresh = cfg.Refresh
+	m.statusbar.Restyle()
+	m.spinner.Style = constants.SpinnerStyle
+	// Keep the order and grouping chosen with the keys, unless the default
+	// changed.
+	order := m.nodelist.Order()
+	if cfg.PeerOrder() != m.peerOrder {
+		m.peerOrder = cfg.PeerOrder()
+		order = m.peerOrder
+	}
+	grouping := m.nodelist.Grouping()
+	if cfg.PeerGrouping() != m.peerGrouping {
+		m.peerGrouping = cfg.PeerGrouping()
+		grouping = m.peerGrouping
+	}
+	// The filters saved in the TUI stay until the end of the session, unless
+	// the config file now has one of the same name.
+	m.savedFilters = cfg.SavedFilters()
+	for _, f := range m.sessionFilters {
+		if !slices.ContainsFunc(m.savedFilters, func(c ts.SavedFilter) bool { return c.Name == f.Name }) {
+			m.savedFilters = append(m.savedFilters, f)
+		}
+	}
+	slices.SortFunc(m.savedFilters, func(a, b ts.SavedFilter) int { return strings.Compare(a.Name, b.Name) })
+	tags := m.nodelist.Tags()
+	m.nodelist = nodelist.New(nil, order, grouping, m.savedFilters, m.w, m.h-m.headerH-m.statusH)
+	m.nodelist.SetTags(tags)
+	m.nodelist.SetTraffic(m.traffic)
+	var cmds []tea.Cmd
+	if m.tsStatus != nil {
+		var cmd tea.Cmd
+		m.nodelist, cmd = m.nodelist.Update(ts.StatusDataMsg(m.tsStatus))
+		cmds = append(cmds, cmd)
+		m.nodelist, cmd = m.nodelist.Update(m.latencies)
+		cmds = append(cmds, cmd)
+	}
+	if restartTraffic {
+		m.trafficGen++
+		if m.tsStatus != nil {
+			cmds = append(cmds, m.pollTraffic())
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// resize fits the views in the space left by the header, which grows with
+// the routes and tags of this device.
+func (m *Model) resize() {
+	m.headerH = lipgloss.Height(m.headerView())
+	if m.w == 0 && m.h == 0 {
+		// Sized on the first WindowSizeMsg.
+		return
+	}
+	contentH := m.h - m.headerH - m.statusH
+	m.nodelist.SetSize(m.w, contentH)
+	m.exitnodelist.SetSize(m.w, contentH)
+	m.routelist.SetSize(m.w, contentH)
+	m.prefsform.SetSize(m.w, contentH)
+	m.profilelist.SetSize(m.w, contentH)
+	m.inbox.SetSize(m.w, contentH)
+	m.netcheck.SetSize(m.w, contentH)
+	m.taglist.SetSize(m.w, contentH)
+	// The details and the login views take the place of the header.
+	m.nodedetails.SetSize(m.w, m.h-m.statusH)
+	m.login.SetSize(m.w, m.h-m.statusH)
+}
+
+func (m *Model) updateInboxBadge() {
+	if n := len(m.waitingFiles); n > 0 {
+		m.statusbar.UpdateSuffix(fmt.Sprintf("Inbox: %d", n))
+	} else {
+		m.statusbar.UpdateSuffix("")
+	}
+}
+
+func (m Model) startLogin() tea.Cmd {
+	return func() tea.Msg {
+		if err := ts.StartLogin(m.backend); err != nil {
+			return ts.LoginErrorMsg(err)
+		}
+		return loginPollMsg{}
+	}
+}
+
+func (m Model) logout() tea.Cmd {
+	return func() tea.Msg {
+		if err := ts.Logout(m.backend); err != nil {
+			return ts.LoginErrorMsg(err)
+		}
+		return types.StatusMsg("Logged out.")
+	}
+}
+
+// editPrefs runs edit in the background and reports the updated prefs,
+// showing done in the status bar on success.
+func (m Model) editPrefs(edit func(ts.Backend) (*ipn.Prefs, error), done string) tea.Cmd {
+	return func() tea.Msg {
+		prefs, err := edit(m.backend)
+		if err != nil {
+			return ts.PrefsErrorMsg(err)
+		}
+		return prefsEditedMsg{prefs: prefs, status: done}
+	}
+}
+
 func (m Model) headerView() string {
 	if m.tsStatus == nil {
-		return nodedetails.NodeDetailRender(nil, tsKey.NodePublic{}, constants.PrimaryTitleStyle.Render("Current Node"))
+		return nodedetails.NodeDetailRender(nil, nil, nil, tsKey.NodePublic{}, constants.PrimaryTitleStyle.Render("Current Node"))
 	}
-	return nodedetails.NodeDetailRender(m.tsStatus, m.tsStatus.Self.PublicKey, constants.PrimaryTitleStyle.Render("Current Node"))
+	return nodedetails.NodeDetailRender(m.tsStatus, m.prefs, nil, m.tsStatus.Self.PublicKey, constants.PrimaryTitleStyle.Render("Current Node"))
 }
 
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
 		m.spinner.Tick,
 		m.getTsStatus(),
+		m.getPrefs(),
+		m.getWaitingFiles(),
+		m.watchBus(),
+	}
+	if m.watcher != nil {
+		cmds = append(cmds, m.pollConfig())
 	}
 	return tea.Batch(cmds...)
 }
@@ -75,30 +469,357 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var tmpCmd tea.Cmd
 	var cmds []tea.Cmd
+	var isData bool
 
 	switch msg := msg.(type) {
 	case ts.StatusDataMsg:
-		m.isLoading = false
+		firstLoad := m.tsStatus == nil
+		wasLoggedOut := ts.NeedsLogin(m.tsStatus)
 		m.Err = nil
 		m.tsStatus = msg
-		m.viewState = viewStateList
-		cmds = append(cmds, types.NewStatusMsg("Showing all network devices"))
+		m.resize()
+		if msg != nil {
+			// The views get the traffic with the status, to be updated once.
+			m.traffic = m.trafficMeter.Sample(msg, time.Now())
+			m.nodelist.SetTraffic(m.traffic)
+			m.nodedetails.SetTraffic(m.traffic)
+		}
+		if m.wantRunning == nil {
+			m.isLoading = false
+		} else if state := msg.BackendState; state != ipn.Starting.String() &&
+			(state != ipn.Stopped.String() || !*m.wantRunning) &&
+			(state != ipn.Running.String() || *m.wantRunning) {
+			// Stopped while connecting and Running while disconnecting are
+			// the states before the change, anything else ends the wait.
+			connecting := *m.wantRunning
+			m.isLoading = false
+			m.wantRunning = nil
+			switch {
+			case state == ipn.Running.String():
+				cmds = append(cmds, types.NewStatusMsg("Connected."))
+			case !connecting:
+				cmds = append(cmds, types.NewStatusMsg("Disconnected."))
+			default:
+				cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Not connected: %s", state)))
+			}
+		}
+		switch {
+		case ts.NeedsLogin(msg) && (firstLoad || !wasLoggedOut):
+			m.login = login.New(msg, m.w, m.h-m.statusH)
+			m.viewState = viewStateLogin
+			cmds = append(cmds, m.login.Init())
+			cmds = append(cmds, types.NewStatusMsg("Login required"))
+			cmds = append(cmds, tea.ClearScreen)
+		case !ts.NeedsLogin(msg) && m.viewState == viewStateLogin:
+			m.viewState = viewStateList
+			cmds = append(cmds, types.NewStatusMsg("Logged in. Showing all network devices"))
+			cmds = append(cmds, tea.ClearScreen)
+		case firstLoad:
+			cmds = append(cmds, types.NewStatusMsg("Showing all network devices"))
+		}
+		if firstLoad && m.probeOpts.Enabled {
+			cmds = append(cmds, m.startProbing())
+		}
+		if firstLoad {
+			cmds = append(cmds, m.pollTraffic())
+		}
 	case ts.StatusErrorMsg:
 		m.isLoading = false
-		m.Err = msg
-		return m, tea.Quit
+		if m.tsStatus == nil {
+			m.Err = msg
+			return m, tea.Quit
+		}
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", msg)))
+	case ts.LoginMsg:
+		cmds = append(cmds, types.NewStatusMsg("Starting login..."))
+		cmds = append(cmds, m.startLogin())
+	case loginPollMsg:
+		if m.viewState == viewStateLogin && !m.busConnected {
+			cmds = append(cmds, m.getTsStatus())
+			cmds = append(cmds, tea.Tick(m.refresh.LoginPoll, func(time.Time) tea.Msg { return loginPollMsg{} }))
+		}
+	case ts.LogoutMsg:
+		cmds = append(cmds, types.NewStatusMsg("Logging out..."))
+		cmds = append(cmds, m.logout())
+	case ts.LoginErrorMsg:
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", msg)))
 	case ts.ToggleConnectionMsg:
-		if m.tsStatus != nil {
+		if m.tsStatus != nil && m.wantRunning == nil {
 			newStatus := !m.tsStatus.Self.Online
 			m.isLoading = true
+			m.wantRunning = &newStatus
+			m.connectGen++
+			gen := m.connectGen
+			cmds = append(cmds, tea.Tick(connectTimeout, func(time.Time) tea.Msg { return connectTimeoutMsg{gen: gen} }))
 			if newStatus {
 				cmds = append(cmds, types.NewStatusMsg("Connecting..."))
 			} else {
 				cmds = append(cmds, types.NewStatusMsg("Disconnecting..."))
 			}
-			ts.SetTSStatus(!m.tsStatus.Self.Online)
-			cmds = append(cmds, func() tea.Msg { time.Sleep(2 * time.Second); return types.RefreshMsg(true) })
+			cmds = append(cmds, m.setTSStatus(newStatus), m.spinner.Tick)
+		}
+	case ts.ConnectMsg:
+		if !m.busConnected {
+			m.wantRunning = nil
+			cmds = append(cmds, m.getTsStatus())
+		}
+	case connectTimeoutMsg:
+		if msg.gen == m.connectGen && m.wantRunning != nil {
+			m.isLoading = false
+			m.wantRunning = nil
+			cmds = append(cmds, types.NewStatusMsg("Timed out waiting for tailscaled."), m.getTsStatus())
+		}
+	case ts.PrefsErrorMsg:
+		m.isLoading = false
+		m.wantRunning = nil
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", msg)))
+	case ts.ProfileErrorMsg:
+		m.isLoading = false
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", msg)))
+	case ts.SwitchProfileMsg:
+		id := ipn.ProfileID(msg)
+		m.isLoading = true
+		cmds = append(cmds, types.NewStatusMsg("Switching profile..."), m.spinner.Tick)
+		cmds = append(cmds, m.editProfiles(func(b ts.Backend) error { return ts.SwitchProfile(b, id) }, "Switched profile."))
+	case ts.DeleteProfileMsg:
+		id := ipn.ProfileID(msg)
+		cmds = append(cmds, types.NewStatusMsg("Deleting profile..."))
+		cmds = append(cmds, m.editProfiles(func(b ts.Backend) error { return ts.DeleteProfile(b, id) }, "Deleted profile."))
+	case ts.NewProfileMsg:
+		m.isLoading = true
+		cmds = append(cmds, types.NewStatusMsg("Creating new profile..."), m.spinner.Tick)
+		cmds = append(cmds, m.editProfiles(ts.NewProfile, "Switched to a new profile."))
+	case profileSwitchedMsg:
+		cmds = append(cmds, types.NewStatusMsg(string(msg)))
+		cmds = append(cmds, m.getProfiles(), m.getPrefs(), m.getTsStatus())
+	case ts.PrefsDataMsg:
+		m.prefs = msg
+		m.resize()
+	case ts.SetExitNodeMsg:
+		id := tailcfg.StableNodeID(msg)
+		if id == "" {
+			cmds = append(cmds, types.NewStatusMsg("Clearing exit node..."))
+			cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.SetExitNode(b, id) }, "Exit node cleared."))
+		} else {
+			cmds = append(cmds, types.NewStatusMsg("Switching exit node..."))
+			cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.SetExitNode(b, id) }, "Exit node switched."))
+		}
+	case ts.AllowLANAccessMsg:
+		allow := bool(msg)
+		done := "LAN access blocked while using an exit node."
+		if allow {
+			done = "LAN access allowed while using an exit node."
+		}
+		cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.SetExitNodeAllowLANAccess(b, allow) }, done))
+	case ts.OfferExitNodeMsg:
+		offer := bool(msg)
+		done := "This device is no longer offered as an exit node."
+		if offer {
+			cmds = append(cmds, types.NewStatusMsg("Advertising this device as an exit node..."))
+			done = "This device is now offered as an exit node."
+		} else {
+			cmds = append(cmds, types.NewStatusMsg("Removing exit node routes..."))
+		}
+		cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.SetOfferExitNode(b, offer) }, done))
+	case ts.AdvertiseRouteMsg:
+		route := netip.Prefix(msg)
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Advertising %s...", route)))
+		cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.AdvertiseRoute(b, route) }, fmt.Sprintf("Advertised %s.", route)))
+	case ts.UnadvertiseRouteMsg:
+		route := netip.Prefix(msg)
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Removing %s...", route)))
+		cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.UnadvertiseRoute(b, route) }, fmt.Sprintf("Removed %s.", route)))
+	case ts.AcceptRoutesMsg:
+		accept := bool(msg)
+		done := "No longer accepting subnet routes from peers."
+		if accept {
+			done = "Accepting subnet routes from peers."
+		}
+		cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.SetAcceptRoutes(b, accept) }, done))
+	case ts.EditPrefsMsg:
+		mp := (*ipn.MaskedPrefs)(msg)
+		cmds = append(cmds, types.NewStatusMsg("Applying preferences..."))
+		cmds = append(cmds, m.editPrefs(func(b ts.Backend) (*ipn.Prefs, error) { return ts.EditPrefs(b, mp) }, "Preferences applied."))
+	case prefsEditedMsg:
+		prefs := msg.prefs
+		m.prefs = prefs
+		m.resize()
+		cmds = append(cmds, types.NewStatusMsg(msg.status))
+		cmds = append(cmds, func() tea.Msg { return ts.PrefsDataMsg(prefs) })
+	case types.ShowExitNodesMsg:
+		if m.tsStatus != nil {
+			m.returnView = m.viewState
+			m.exitnodelist = exitnodelist.New(m.tsStatus, m.prefs, m.w, m.h-m.headerH-m.statusH)
+			m.viewState = viewStateExitNodes
+			cmds = append(cmds, types.NewStatusMsg("Showing exit nodes"))
+			cmds = append(cmds, tea.ClearScreen)
+		}
+	case types.ShowRoutesMsg:
+		if m.tsStatus != nil {
+			m.returnView = m.viewState
+			m.routelist = routelist.New(m.tsStatus, m.prefs, m.w, m.h-m.headerH-m.statusH)
+			m.viewState = viewStateRoutes
+			cmds = append(cmds, types.NewStatusMsg("Showing subnet routes"))
+			cmds = append(cmds, tea.ClearScreen)
 		}
+	case types.ShowPrefsMsg:
+		if m.tsStatus != nil {
+			m.returnView = m.viewState
+			m.prefsform = prefsform.New(m.backend, m.w, m.h-m.headerH-m.statusH)
+			m.viewState = viewStatePrefs
+			cmds = append(cmds, m.prefsform.Init())
+			cmds = append(cmds, types.NewStatusMsg("Editing preferences"))
+			cmds = append(cmds, tea.ClearScreen)
+		}
+	case ts.WaitingFilesMsg:
+		m.waitingFiles = msg
+		m.updateInboxBadge()
+	case ts.SaveFileMsg:
+		name, dir := msg.Name, msg.Dir
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Saving %s...", name)))
+		cmds = append(cmds, m.editInbox(func(b ts.Backend) (string, error) {
+			path, err := ts.SaveWaitingFile(b, name, dir)
+			return fmt.Sprintf("Saved %s.", path), err
+		}))
+	case ts.DiscardFileMsg:
+		name := string(msg)
+		cmds = append(cmds, m.editInbox(func(b ts.Backend) (string, error) {
+			return fmt.Sprintf("Discarded %s.", name), ts.DiscardWaitingFile(b, name)
+		}))
+	case taildropDoneMsg:
+		cmds = append(cmds, types.NewStatusMsg(string(msg)))
+		cmds = append(cmds, m.getWaitingFiles())
+	case ts.TaildropErrorMsg:
+		// The inbox is polled in the background, only report errors while
+		// it is open.
+		if m.viewState == viewStateInbox {
+			cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", msg)))
+		}
+	case types.ShowInboxMsg:
+		m.returnView = m.viewState
+		m.inbox = inbox.New(m.waitingFiles, m.w, m.h-m.headerH-m.statusH)
+		m.viewState = viewStateInbox
+		cmds = append(cmds, m.getWaitingFiles())
+		cmds = append(cmds, types.NewStatusMsg("Showing Taildrop inbox"))
+		cmds = append(cmds, tea.ClearScreen)
+	case types.ShowNetcheckMsg:
+		m.returnView = m.viewState
+		m.netcheck = netcheck.New(m.tsStatus, m.w, m.h-m.headerH-m.statusH)
+		m.viewState = viewStateNetcheck
+		cmds = append(cmds, m.netcheck.Init())
+		cmds = append(cmds, types.NewStatusMsg("Showing network check"))
+		cmds = append(cmds, tea.ClearScreen)
+	case ts.RunNetcheckMsg:
+		cmds = append(cmds, m.runNetcheck())
+	case ts.NetcheckDataMsg:
+		cmds = append(cmds, types.NewStatusMsg("Netcheck finished."))
+	case ts.NetcheckErrorMsg:
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", msg)))
+	case types.ShowProfilesMsg:
+		m.returnView = m.viewState
+		m.profilelist = profilelist.New(m.w, m.h-m.headerH-m.statusH)
+		m.viewState = viewStateProfiles
+		cmds = append(cmds, m.getProfiles())
+		cmds = append(cmds, types.NewStatusMsg("Showing login profiles"))
+		cmds = append(cmds, tea.ClearScreen)
+	case types.ShowTagsMsg:
+		if m.tsStatus != nil {
+			m.returnView = m.viewState
+			m.taglist = taglist.New(m.tsStatus, m.nodelist.Tags(), m.w, m.h-m.headerH-m.statusH)
+			m.viewState = viewStateTags
+			cmds = append(cmds, types.NewStatusMsg("Showing ACL tags"))
+			cmds = append(cmds, tea.ClearScreen)
+		}
+	case taglist.TagsSelectedMsg:
+		m.viewState = m.returnView
+		cmds = append(cmds, m.nodelist.SetTags(msg))
+		if len(msg) == 0 {
+			cmds = append(cmds, types.NewStatusMsg("Showing all network devices"))
+		} else {
+			cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Showing devices tagged %s", strings.Join(msg, ", "))))
+		}
+		cmds = append(cmds, tea.ClearScreen)
+	case exitnodelist.BackMsg, routelist.BackMsg, prefsform.BackMsg, profilelist.BackMsg, inbox.BackMsg, netcheck.BackMsg, taglist.BackMsg:
+		m.viewState = m.returnView
+		if m.viewState == viewStateLogin && !ts.NeedsLogin(m.tsStatus) {
+			m.viewState = viewStateList
+		}
+		cmds = append(cmds, types.NewStatusMsg("Showing all network devices"))
+		cmds = append(cmds, tea.ClearScreen)
+	case ts.ToggleProbeMsg:
+		if m.stopProbe != nil {
+			cmds = append(cmds, m.stopProbing())
+		} else {
+			cmds = append(cmds, m.startProbing())
+		}
+	case ts.ProbeDataMsg:
+		m.latencies = msg
+	case trafficTickMsg:
+		if msg.gen == m.trafficGen {
+			cmds = append(cmds, m.getTsStatus())
+			cmds = append(cmds, m.pollTraffic())
+		}
+	case configPollMsg:
+		if m.watcher.Changed() {
+			cmds = append(cmds, m.loadConfig())
+		}
+		cmds = append(cmds, m.pollConfig())
+	case configLoadedMsg:
+		cmds = append(cmds, m.applyConfig(config.Config(msg)))
+		cmds = append(cmds, types.NewStatusMsg("Config reloaded."))
+	case configErrorMsg:
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Config not reloaded: %s", msg.err)))
+	case probeDoneMsg:
+		if msg.ctx.Err() == nil {
+			latencies, ctx := msg.latencies, msg.ctx
+			cmds = append(cmds, func() tea.Msg { return latencies })
+			cmds = append(cmds, tea.Tick(m.probeOpts.Interval, func(time.Time) tea.Msg { return probeTickMsg{ctx: ctx} }))
+		}
+	case probeTickMsg:
+		if msg.ctx.Err() == nil {
+			cmds = append(cmds, m.probePeers(msg.ctx))
+		}
+	case ts.BusNotifyMsg:
+		m.busConnected = true
+		if msg.Prefs != nil && msg.Prefs.Valid() {
+			prefs := msg.Prefs.AsStruct()
+			cmds = append(cmds, func() tea.Msg { return ts.PrefsDataMsg(prefs) })
+		}
+		if msg.State != nil || msg.Prefs != nil || msg.NetMap != nil {
+			cmds = append(cmds, m.scheduleRefresh())
+		}
+		if msg.BrowseToURL != nil {
+			url := *msg.BrowseToURL
+			cmds = append(cmds, func() tea.Msg { return ts.AuthURLMsg(url) })
+		}
+		if msg.LoginFinished != nil {
+			cmds = append(cmds, types.NewStatusMsg("Login successful."))
+			cmds = append(cmds, m.scheduleRefresh())
+		}
+		if msg.FilesWaiting != nil {
+			cmds = append(cmds, m.getWaitingFiles())
+		}
+		if msg.ErrMessage != nil {
+			cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Error: %s", *msg.ErrMessage)))
+		}
+		cmds = append(cmds, m.waitForBus())
+	case busWatchMsg:
+		m.bus = msg.w
+		m.busBackoff = ts.BusMinBackoff
+		cmds = append(cmds, m.waitForBus())
+	case ts.BusErrorMsg:
+		m.busConnected = false
+		if m.busCtx.Err() != nil {
+			break
+		}
+		m.busBackoff = ts.NextBusBackoff(msg.RetryIn)
+		cmds = append(cmds, types.NewStatusMsg(fmt.Sprintf("Lost connection to tailscaled, retrying in %s...", msg.RetryIn)))
+		cmds = append(cmds, tea.Tick(msg.RetryIn, func(time.Time) tea.Msg { return busRetryMsg{} }))
+	case busRetryMsg:
+		cmds = append(cmds, m.watchBus())
+	case busRefreshMsg:
+		m.refreshPending = false
+		cmds = append(cmds, m.getTsStatus())
 	case nodedetails.BackMsg:
 		m.viewState = viewStateList
 		cmds = append(cmds, types.NewStatusMsg("Showing all network devices"))
@@ -106,26 +827,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case types.RefreshMsg:
 		m.isLoading = true
 		cmds = append(cmds, m.getTsStatus())
+		cmds = append(cmds, m.getPrefs())
+		cmds = append(cmds, m.getWaitingFiles())
 		cmds = append(cmds, m.spinner.Tick)
 	case types.StatusMsg:
 		m.statusbar.UpdateMessage(string(msg))
 	case types.ExitMsg:
 		m.ExitMessage = string(msg)
 		return m, tea.Quit
+	case nodelist.FilterSavedMsg:
+		m.sessionFilters = append(m.sessionFilters, ts.SavedFilter(msg))
 	case nodelist.NodeSelectedMsg:
 		m.selectedNodeID = tsKey.NodePublic(msg)
 		contentH := m.h - m.statusH
-		m.nodedetails = nodedetails.New(m.tsStatus, m.selectedNodeID, m.w, contentH)
+		m.nodedetails = nodedetails.New(m.backend, m.tsStatus, m.prefs, m.traffic, m.selectedNodeID, m.pingOpts, m.w, contentH)
 		m.viewState = viewStateDetails
 		cmds = append(cmds, types.NewStatusMsg("Showing device details"))
 		cmds = append(cmds, tea.ClearScreen)
 	case tea.WindowSizeMsg:
 		m.w, m.h = msg.Width, msg.Height
-		m.headerH = lipgloss.Height(m.headerView())
 		m.statusH = lipgloss.Height(m.statusbar.View())
-		contentH := m.h - m.headerH - m.statusH
-		m.nodelist.SetSize(m.w, contentH)
-		m.nodedetails.SetSize(m.w, contentH)
+		m.resize()
 	case spinner.TickMsg:
 		if m.isLoading {
 			m.spinner, tmpCmd = m.spinner.Update(msg)
@@ -139,11 +861,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusbar.UpdatePrefixStyle(constants.PrimaryTitleStyle)
 	}
 
-	switch m.viewState {
-	case viewStateDetails:
+	switch msg.(type) {
+	case ts.StatusDataMsg, ts.PrefsDataMsg, ts.ProfilesDataMsg, ts.WaitingFilesMsg, ts.ProbeDataMsg:
+		isData = true
+	}
+	switch {
+	case isData:
+		m.nodelist, tmpCmd = m.nodelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
 		m.nodedetails, tmpCmd = m.nodedetails.Update(msg)
 		cmds = append(cmds, tmpCmd)
-	case viewStateList:
+		m.exitnodelist, tmpCmd = m.exitnodelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.routelist, tmpCmd = m.routelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.prefsform, tmpCmd = m.prefsform.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.profilelist, tmpCmd = m.profilelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.login, tmpCmd = m.login.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.inbox, tmpCmd = m.inbox.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.netcheck, tmpCmd = m.netcheck.Update(msg)
+		cmds = append(cmds, tmpCmd)
+		m.taglist, tmpCmd = m.taglist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateDetails:
+		m.nodedetails, tmpCmd = m.nodedetails.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateExitNodes:
+		m.exitnodelist, tmpCmd = m.exitnodelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateRoutes:
+		m.routelist, tmpCmd = m.routelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStatePrefs:
+		m.prefsform, tmpCmd = m.prefsform.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateProfiles:
+		m.profilelist, tmpCmd = m.profilelist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateLogin:
+		m.login, tmpCmd = m.login.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateInbox:
+		m.inbox, tmpCmd = m.inbox.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateNetcheck:
+		m.netcheck, tmpCmd = m.netcheck.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateTags:
+		m.taglist, tmpCmd = m.taglist.Update(msg)
+		cmds = append(cmds, tmpCmd)
+	case m.viewState == viewStateList:
 		if m.isLoading {
 			m.spinner, tmpCmd = m.spinner.Update(msg)
 			cmds = append(cmds, tmpCmd)
@@ -169,25 +940,65 @@ func (m Model) View() string {
 			m.statusbar.UpdateMessage(fmt.Sprintf("%s Loading...", m.spinner.View()))
 		}
 		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.nodelist.View(), m.statusbar.View())
+	case viewStateExitNodes:
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.exitnodelist.View(), m.statusbar.View())
+	case viewStateRoutes:
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.routelist.View(), m.statusbar.View())
+	case viewStatePrefs:
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.prefsform.View(), m.statusbar.View())
+	case viewStateLogin:
+		return lipgloss.JoinVertical(lipgloss.Left, lipgloss.NewStyle().Height(m.h-m.statusH).Render(m.login.View()), m.statusbar.View())
+	case viewStateProfiles:
+		if m.isLoading {
+			m.statusbar.UpdateMessage(fmt.Sprintf("%s %s", m.spinner.View(), m.statusbar.Message()))
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.profilelist.View(), m.statusbar.View())
+	case viewStateInbox:
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.inbox.View(), m.statusbar.View())
+	case viewStateNetcheck:
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.netcheck.View(), m.statusbar.View())
+	case viewStateTags:
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.taglist.View(), m.statusbar.View())
 	default:
 		return "*_*"
 	}
 }
 
-func New() Model {
+// New creates the TUI with cfg, reloading it from watcher when the config
+// file changes if it is not nil.
+func New(backend ts.Backend, cfg config.Config, watcher *config.Watcher) Model {
+	cfg.Apply()
 	m := Model{
-		viewState: viewStateList,
-		isLoading: true,
-		spinner:   spinner.New(),
-		statusbar: statusbar.New(),
+		backend:      backend,
+		pingOpts:     cfg.PingOptions(),
+		probeOpts:    cfg.ProbeOptions(),
+		peerOrder:    cfg.PeerOrder(),
+		peerGrouping: cfg.PeerGrouping(),
+		savedFilters: cfg.SavedFilters(),
+		refresh:      cfg.Refresh,
+		watcher:      watcher,
+		viewState:    viewStateList,
+		isLoading:    true,
+		spinner:      spinner.New(),
+		statusbar:    statusbar.New(),
+		busBackoff:   ts.BusMinBackoff,
 	}
+	m.busCtx, m.stopBus = context.WithCancel(context.Background())
 	m.spinner.Spinner = spinner.Line
 	m.spinner.Style = constants.SpinnerStyle
 
 	m.headerH = lipgloss.Height(m.headerView())
 	m.statusH = lipgloss.Height(m.statusbar.View())
 	contentH := m.h - m.headerH - m.statusH
-	m.nodelist = nodelist.New(nil, m.w, contentH)
-	m.nodedetails = nodedetails.New(m.tsStatus, tsKey.NodePublic{}, m.w, contentH)
+	m.nodelist = nodelist.New(nil, m.peerOrder, m.peerGrouping, m.savedFilters, m.w, contentH)
+	m.nodedetails = nodedetails.New(m.backend, m.tsStatus, m.prefs, m.traffic, tsKey.NodePublic{}, m.pingOpts, m.w, contentH)
+	m.exitnodelist = exitnodelist.New(m.tsStatus, m.prefs, m.w, contentH)
+	m.routelist = routelist.New(m.tsStatus, m.prefs, m.w, contentH)
+	m.prefsform = prefsform.New(m.backend, m.w, contentH)
+	m.profilelist = profilelist.New(m.w, contentH)
+	m.login = login.New(m.tsStatus, m.w, m.h-m.statusH)
+	m.inbox = inbox.New(nil, m.w, contentH)
+	m.netcheck = netcheck.New(m.tsStatus, m.w, contentH)
+	m.taglist = taglist.New(m.tsStatus, nil, m.w, contentH)
 	return m
 }
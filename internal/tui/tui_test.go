@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/bilguun0203/tailscale-tui/internal/config"
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
+	nodelist "github.com/bilguun0203/tailscale-tui/internal/tui/node_list"
+	"github.com/bilguun0203/tailscale-tui/internal/tui/tuitest"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
+)
+
+func update(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	tm, cmd := m.Update(msg)
+	return tm.(Model), cmd
+}
+
+// newHarness returns a sized model of a fake backend serving status, it
+// is not started.
+func newHarness(t *testing.T, status *ipnstate.Status) (*tuitest.Harness[Model], *ts.FakeBackend) {
+	f := ts.NewFakeBackend(status)
+	cfg := config.Default()
+	cfg.Refresh.Traffic = 0
+	m := New(f, cfg, nil)
+	t.Cleanup(m.Close)
+	h := tuitest.New(t, m, update)
+	h.Send(tea.WindowSizeMsg{Width: 120, Height: 40})
+	return h, f
+}
+
+// start runs Init and waits for the status and the IPN bus.
+func start(t *testing.T, status *ipnstate.Status) (*tuitest.Harness[Model], *ts.FakeBackend) {
+	h, f := newHarness(t, status)
+	h.Run(h.Model.Init())
+	h.Await("the status and the IPN bus", func(m Model) bool {
+		return m.tsStatus != nil && m.prefs != nil && m.busConnected && !m.isLoading
+	})
+	return h, f
+}
+
+func TestToggleConnection(t *testing.T) {
+	h, f := start(t, ts.FakeStatus())
+	for _, want := range []bool{false, true, false} {
+		h.Send(ts.ToggleConnectionMsg(true))
+		state := ipn.Stopped
+		if want {
+			state = ipn.Running
+		}
+		h.Await("the connection to be "+state.String(), func(m Model) bool {
+			return m.wantRunning == nil && !m.isLoading &&
+				m.tsStatus.BackendState == state.String() && m.tsStatus.Self.Online == want
+		})
+		if prefs, _ := ts.GetPrefs(f); prefs.WantRunning != want {
+			t.Errorf("toggled to %t: WantRunning %t", want, prefs.WantRunning)
+		}
+	}
+	if n := len(f.Edits()); n != 3 {
+		t.Errorf("%d edits, want 3", n)
+	}
+}
+
+func TestBusReconnect(t *testing.T) {
+	h, f := start(t, ts.FakeStatus())
+	f.DropWatchers()
+	h.Await("the IPN bus to drop", func(m Model) bool { return !m.busConnected })
+	if h.Model.busBackoff != 2*ts.BusMinBackoff {
+		t.Errorf("backoff %s, want %s", h.Model.busBackoff, 2*ts.BusMinBackoff)
+	}
+	h.Await("the IPN bus to reconnect", func(m Model) bool { return m.busConnected })
+	if h.Model.busBackoff != ts.BusMinBackoff {
+		t.Errorf("backoff %s after reconnecting, want %s", h.Model.busBackoff, ts.BusMinBackoff)
+	}
+
+	// Notifications reach the model again.
+	next := ts.FakeStatus()
+	next.BackendState = ipn.NeedsLogin.String()
+	f.SetStatus(next, nil)
+	state := ipn.NeedsLogin
+	f.Publish(ipn.Notify{State: &state})
+	h.Await("the login view", func(m Model) bool { return m.viewState == viewStateLogin })
+}
+
+func TestConnectionWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		online  bool
+		states  []ipn.State
+		waiting []bool // after each state
+	}{
+		{"connect", false, []ipn.State{ipn.Stopped, ipn.Starting, ipn.Running}, []bool{true, true, false}},
+		{"disconnect", true, []ipn.State{ipn.Running, ipn.Stopped}, []bool{true, false}},
+		{"needs login", false, []ipn.State{ipn.Starting, ipn.NeedsLogin}, []bool{true, false}},
+		{"needs machine auth", false, []ipn.State{ipn.NeedsMachineAuth}, []bool{false}},
+		{"logged out while disconnecting", true, []ipn.State{ipn.NeedsLogin}, []bool{false}},
+	}
+	for _, tt := range tests {
+		status := ts.FakeStatus()
+		status.Self.Online = tt.online
+		h, _ := newHarness(t, status)
+		// The bus reports the state changes, the commands of the toggle are
+		// left out to feed them one by one.
+		m, _ := update(h.Model, ts.StatusDataMsg(status))
+		m.busConnected = true
+		m, _ = update(m, ts.ToggleConnectionMsg(true))
+		for i, state := range tt.states {
+			next := *status
+			next.BackendState = state.String()
+			m, _ = update(m, ts.StatusDataMsg(&next))
+			if waiting := m.isLoading || m.wantRunning != nil; waiting != tt.waiting[i] {
+				t.Errorf("%s: waiting %t after %s, want %t", tt.name, waiting, state, tt.waiting[i])
+			}
+		}
+		// Toggles work again once the wait is over.
+		if m, _ = update(m, ts.ToggleConnectionMsg(true)); m.wantRunning == nil {
+			t.Errorf("%s: toggle ignored", tt.name)
+		}
+	}
+}
+
+func TestConnectionTimeout(t *testing.T) {
+	status := ts.FakeStatus()
+	h, _ := newHarness(t, status)
+	m, _ := update(h.Model, ts.StatusDataMsg(status))
+	m.busConnected = true
+	m, _ = update(m, ts.ToggleConnectionMsg(true))
+	if m, _ = update(m, connectTimeoutMsg{gen: m.connectGen - 1}); m.wantRunning == nil {
+		t.Error("stale timeout ended the wait")
+	}
+	if m, _ = update(m, connectTimeoutMsg{gen: m.connectGen}); m.isLoading || m.wantRunning != nil {
+		t.Error("still waiting after the timeout")
+	}
+}
+
+func TestHeaderResize(t *testing.T) {
+	status := ts.FakeStatus()
+	h, _ := newHarness(t, status)
+	h.Send(ts.StatusDataMsg(status))
+	headerH := h.Model.headerH
+	prefs := ipn.NewPrefs()
+	prefs.AdvertiseRoutes = []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}
+	h.Send(ts.PrefsDataMsg(prefs))
+	if h.Model.headerH <= headerH {
+		t.Errorf("header height %d with routes, want more than %d", h.Model.headerH, headerH)
+	}
+	if got := lipgloss.Height(h.Model.View()); got != h.Model.h {
+		t.Errorf("list view height %d, want %d", got, h.Model.h)
+	}
+
+	// The details view has no header, refreshes keep it at full height.
+	h.Send(nodelist.NodeSelectedMsg(status.Self.PublicKey), ts.StatusDataMsg(status))
+	if got := lipgloss.Height(h.Model.View()); h.Model.viewState != viewStateDetails || got != h.Model.h {
+		t.Errorf("details view height %d, want %d", got, h.Model.h)
+	}
+}
+
+func TestSessionFilters(t *testing.T) {
+	h, _ := newHarness(t, ts.FakeStatus())
+	h.Send(nodelist.FilterSavedMsg{Name: "linux", Query: "os:linux"}, nodelist.FilterSavedMsg{Name: "servers", Query: "tag:server"})
+
+	cfg := config.Default()
+	cfg.Refresh.Traffic = 0
+	cfg.Filters = map[string]string{"offline": "online:false", "servers": "tag:server online:true"}
+	h.Run(h.Model.applyConfig(cfg))
+	want := []ts.SavedFilter{
+		{Name: "linux", Query: "os:linux"},
+		{Name: "offline", Query: "online:false"},
+		{Name: "servers", Query: "tag:server online:true"},
+	}
+	if got := h.Model.savedFilters; !slices.Equal(got, want) {
+		t.Errorf("saved filters after reloading the config %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+// Package tuitest drives Bubble Tea models in tests the way the program
+// does: messages are applied one at a time and commands run concurrently.
+package tuitest
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Timeout is how long Await waits for a model to reach the expected state.
+var Timeout = 5 * time.Second
+
+// Harness applies messages to a model and the messages returned by its
+// commands. Commands still running when the test ends are abandoned.
+type Harness[M any] struct {
+	Model M
+
+	t      testing.TB
+	update func(M, tea.Msg) (M, tea.Cmd)
+	msgs   chan tea.Msg
+	done   chan struct{}
+}
+
+// New returns a harness for m, update is its Update method.
+func New[M any](t testing.TB, m M, update func(M, tea.Msg) (M, tea.Cmd)) *Harness[M] {
+	h := &Harness[M]{
+		Model:  m,
+		t:      t,
+		update: update,
+		msgs:   make(chan tea.Msg),
+		done:   make(chan struct{}),
+	}
+	t.Cleanup(func() { close(h.done) })
+	return h
+}
+
+// Send applies msgs in order and starts the commands they return.
+func (h *Harness[M]) Send(msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		var cmd tea.Cmd
+		h.Model, cmd = h.update(h.Model, msg)
+		h.Run(cmd)
+	}
+}
+
+// Run starts cmd, its message is applied by Await.
+func (h *Harness[M]) Run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, cmd := range batch {
+				h.Run(cmd)
+			}
+			return
+		}
+		if msg == nil {
+			return
+		}
+		select {
+		case h.msgs <- msg:
+		case <-h.done:
+		}
+	}()
+}
+
+// Await applies the messages of the commands until cond holds, and fails
+// the test if it still does not after Timeout.
+func (h *Harness[M]) Await(what string, cond func(M) bool) {
+	h.t.Helper()
+	deadline := time.After(Timeout)
+	for !cond(h.Model) {
+		select {
+		case msg := <-h.msgs:
+			h.Send(msg)
+		case <-deadline:
+			h.t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// Key returns the message of a key press, like "enter", "esc" or "a".
+func Key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "ctrl+s":
+		return tea.KeyMsg{Type: tea.KeyCtrlS}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
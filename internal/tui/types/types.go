@@ -5,6 +5,13 @@ import tea "github.com/charmbracelet/bubbletea"
 type RefreshMsg bool
 type StatusMsg string
 type ExitMsg string
+type ShowExitNodesMsg bool
+type ShowRoutesMsg bool
+type ShowPrefsMsg bool
+type ShowProfilesMsg bool
+type ShowInboxMsg bool
+type ShowNetcheckMsg bool
+type ShowTagsMsg bool
 
 func NewStatusMsg(msg string) func() tea.Msg {
 	return func() tea.Msg { return StatusMsg(msg) }
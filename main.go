@@ -1,18 +1,69 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
+	"github.com/bilguun0203/tailscale-tui/internal/cli"
+	"github.com/bilguun0203/tailscale-tui/internal/config"
+	"github.com/bilguun0203/tailscale-tui/internal/ts"
 	"github.com/bilguun0203/tailscale-tui/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+func bindFlags(fs *flag.FlagSet, path *string, cfg *config.Config) {
+	fs.StringVar(path, "config", *path, "path of the config file")
+	fs.IntVar(&cfg.Ping.Count, "ping-count", cfg.Ping.Count, "max number of pings to send, 0 for no limit")
+	fs.DurationVar(&cfg.Ping.Interval, "ping-interval", cfg.Ping.Interval, "wait between ping replies")
+	fs.DurationVar(&cfg.Ping.Timeout, "ping-timeout", cfg.Ping.Timeout, "timeout before giving up on a ping")
+	fs.BoolVar(&cfg.Probe.Enabled, "probe", cfg.Probe.Enabled, "probe the latency of online peers in the background")
+	fs.IntVar(&cfg.Probe.Concurrency, "probe-concurrency", cfg.Probe.Concurrency, "max number of probes in flight")
+	fs.DurationVar(&cfg.Probe.Rate, "probe-rate", cfg.Probe.Rate, "min wait between sending two probes")
+	fs.DurationVar(&cfg.Probe.Interval, "probe-interval", cfg.Probe.Interval, "wait between two rounds of probes")
+}
+
 func main() {
-	m := tui.New()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\n%s\nFlags:\n", os.Args[0], cli.Usage)
+		flag.PrintDefaults()
+	}
+	path := config.DefaultPath()
+	defaults := config.Default()
+	bindFlags(flag.CommandLine, &path, &defaults)
+	flag.Parse()
+	// Flags win over the config file, also when it is reloaded.
+	override := func(cfg *config.Config) {
+		fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+		var ignored string
+		bindFlags(fs, &ignored, cfg)
+		fs.Parse(os.Args[1:])
+	}
+	watcher := config.NewWatcher(path, override)
+	cfg, err := watcher.Load()
+	if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+
+	if flag.NArg() > 0 {
+		if err := cli.Run(ts.NewLocalBackend(), cfg.PingOptions(), flag.Args(), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			if errors.As(err, &cli.UsageError{}) {
+				flag.Usage()
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	m := tui.New(ts.NewLocalBackend(), cfg, watcher)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	fm, err := p.Run()
+	m.Close()
 
 	if err != nil {
 		fmt.Println("Error running program:", err)